@@ -34,17 +34,18 @@ const insLimit = 20000
 //-----------------------------------------------------------------------------
 
 type testCase struct {
-	baseName string
-	testName string
-	elfFile  string
-	sigFile  string
-	elfClass elf.Class
+	baseName  string
+	testName  string
+	elfFile   string
+	sigFile   string
+	elfClass  elf.Class
+	bigEndian bool
 }
 
 const sigSuffix = ".signature.output"
 const elfSuffix = ".elf"
 
-func getTestCases(testPath string) ([]*testCase, error) {
+func getTestCases(testPath string, bigEndian bool) ([]*testCase, error) {
 	x := []*testCase{}
 	err := filepath.Walk(testPath, func(path string, info os.FileInfo, err error) error {
 		class, err := util.GetELFClass(path)
@@ -60,11 +61,12 @@ func getTestCases(testPath string) ([]*testCase, error) {
 			}
 		}
 		tc := testCase{
-			baseName: testPath,
-			testName: strings.TrimPrefix(path, testPath+"/"),
-			elfFile:  path,
-			sigFile:  sigFile,
-			elfClass: class,
+			baseName:  testPath,
+			testName:  strings.TrimPrefix(path, testPath+"/"),
+			elfFile:   path,
+			sigFile:   sigFile,
+			elfClass:  class,
+			bigEndian: bigEndian,
 		}
 		x = append(x, &tc)
 		return nil
@@ -195,13 +197,14 @@ func (tc *testCase) Test() error {
 	}
 
 	// load the elf file
+	cpu.Mem.SetBigEndian(tc.bigEndian)
 	_, err := cpu.Mem.LoadELF(tc.elfFile, tc.elfClass)
 	if err != nil {
 		return err
 	}
 
 	// add a heap
-	cpu.Mem.Add(mem.NewSection("heap", 0x80000000, heapSize, mem.AttrRW))
+	cpu.Mem.AddBacking(cpu.Mem.NewSection("heap", 0x80000000, heapSize, mem.AttrRW))
 
 	// Callback on the "tohost" write (compliance tests).
 	var tohost *host.Host
@@ -270,11 +273,18 @@ func (tc *testCase) Test() error {
 func main() {
 	// command line flags
 	path := flag.String("p", "test", "path to compliance tests")
+	endian := flag.String("endian", "le", "data byte order (le or be)")
 	flag.Parse()
 	testPath := filepath.Clean(*path)
 
+	bigEndian, err := mem.EndianArg(*endian)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		os.Exit(1)
+	}
+
 	// get the test cases
-	testCases, err := getTestCases(testPath)
+	testCases, err := getTestCases(testPath, bigEndian)
 	if err != nil {
 		fmt.Printf("%s\n", err)
 		os.Exit(1)
@@ -10,6 +10,7 @@ package main
 
 import (
 	"fmt"
+	"math"
 
 	cli "github.com/deadsy/go-cli"
 	"github.com/deadsy/riscv/csr"
@@ -279,6 +280,16 @@ var cmdDisassemble = cli.Leaf{
 
 //-----------------------------------------------------------------------------
 
+var cmdBacktrace = cli.Leaf{
+	Descr: "display the call stack",
+	F: func(c *cli.CLI, args []string) {
+		m := c.User.(*emuApp).cpu
+		c.User.Put(fmt.Sprintf("%s\n", m.DisplayBacktrace(32)))
+	},
+}
+
+//-----------------------------------------------------------------------------
+
 var cmdFloatRegisters = cli.Leaf{
 	Descr: "display float registers",
 	F: func(c *cli.CLI, args []string) {
@@ -335,6 +346,22 @@ var cmdMap = cli.Leaf{
 	},
 }
 
+var cmdMemMap = cli.Leaf{
+	Descr: "display the memory layout",
+	F: func(c *cli.CLI, args []string) {
+		m := c.User.(*emuApp).mem
+		c.User.Put(fmt.Sprintf("%s\n", m.Layout()))
+	},
+}
+
+var cmdMemoryMap = cli.Leaf{
+	Descr: "display the memory map with empty regions",
+	F: func(c *cli.CLI, args []string) {
+		m := c.User.(*emuApp).mem
+		c.User.Put(fmt.Sprintf("%s\n", m.NewMemoryMap()))
+	},
+}
+
 //-----------------------------------------------------------------------------
 // memory monitors
 
@@ -368,6 +395,81 @@ var cmdBreakPointShow = cli.Leaf{
 	},
 }
 
+//-----------------------------------------------------------------------------
+// memory watch points
+
+var helpWatch = []cli.Help{
+	{"<adr> <size> [r|w|rw]", "address (hex) - no arguments shows the watch points"},
+	{"", "size (hex)"},
+	{"", "access mode - default is rw"},
+}
+
+var cmdWatch = cli.Leaf{
+	Descr: "add a memory watch point",
+	F: func(c *cli.CLI, args []string) {
+		m := c.User.(*emuApp).mem
+		err := cli.CheckArgc(args, []int{0, 2, 3})
+		if err != nil {
+			c.User.Put(fmt.Sprintf("%s\n", err))
+			return
+		}
+		if len(args) == 0 {
+			c.User.Put(fmt.Sprintf("%s\n", m.DisplayWatches()))
+			return
+		}
+		adr, size, err := util.MemArg(0, maxAdr, args[0:2])
+		if err != nil {
+			c.User.Put(fmt.Sprintf("%s\n", err))
+			return
+		}
+		mode := mem.WatchReadWrite
+		if len(args) == 3 {
+			mode, err = mem.WatchModeArg(args[2])
+			if err != nil {
+				c.User.Put(fmt.Sprintf("%s\n", err))
+				return
+			}
+		}
+		var id int
+		fn := func(adr, size uint, isWrite bool, val uint64) {
+			access := "read"
+			if isWrite {
+				access = "write"
+			}
+			c.User.Put(fmt.Sprintf("watch %d: %s %s (%d bytes) 0x%x\n", id, access, m.AddrStr(adr), size, val))
+		}
+		id = m.SetWatch(adr, size, mode, fn)
+		c.User.Put(fmt.Sprintf("watch %d: %s %d %s\n", id, m.AddrStr(adr), size, mode))
+	},
+}
+
+var helpUnwatch = []cli.Help{
+	{"<id>", "watch point identifier"},
+}
+
+var cmdUnwatch = cli.Leaf{
+	Descr: "remove a memory watch point",
+	F: func(c *cli.CLI, args []string) {
+		m := c.User.(*emuApp).mem
+		err := cli.CheckArgc(args, []int{1})
+		if err != nil {
+			c.User.Put(fmt.Sprintf("%s\n", err))
+			return
+		}
+		id, err := cli.IntArg(args[0], [2]int{1, math.MaxInt32}, 10)
+		if err != nil {
+			c.User.Put(fmt.Sprintf("%s\n", err))
+			return
+		}
+		err = m.ClearWatch(id)
+		if err != nil {
+			c.User.Put(fmt.Sprintf("%s\n", err))
+		}
+	},
+}
+
+//-----------------------------------------------------------------------------
+
 // memBreakPointMenu submenu items
 var memBreakPointMenu = cli.Menu{
 	{"add", cmdBreakPointAdd, helpBreakPointAdd},
@@ -447,6 +549,7 @@ var cmdErrors = cli.Leaf{
 
 // root menu
 var menuRoot = cli.Menu{
+	{"bt", cmdBacktrace},
 	{"csr", cmdCSR},
 	{"da", cmdDisassemble, helpDisassemble},
 	{"errors", cmdErrors},
@@ -456,7 +559,9 @@ var menuRoot = cli.Menu{
 	{"history", cmdHistory, cli.HistoryHelp},
 	{"host", cmdHost},
 	{"map", cmdMap},
+	{"memmap", cmdMemMap},
 	{"mm", memBreakPointMenu, "memory monitor functions"},
+	{"mmap", cmdMemoryMap},
 	{"pm", memDisplayPm, "physical memory menu"},
 	{"pt", cmdPageTable, helpPageTable},
 	{"rf", cmdFloatRegisters},
@@ -465,7 +570,9 @@ var menuRoot = cli.Menu{
 	{"step", cmdStep, helpGo},
 	{"sym", cmdSymbol},
 	{"trace", cmdTrace, helpGo},
+	{"unwatch", cmdUnwatch, helpUnwatch},
 	{"vm", memDisplayVm, "virtual memory menu"},
+	{"watch", cmdWatch, helpWatch},
 }
 
 //-----------------------------------------------------------------------------
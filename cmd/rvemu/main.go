@@ -10,6 +10,7 @@ package main
 
 import (
 	"debug/elf"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -38,11 +39,30 @@ type emuApp struct {
 	prompt   string
 }
 
+// newISA returns an ISA built from the base modules and (optionally) the extension modules.
+func newISA(base, ext []rv.ISAModule, addExt bool) (*rv.ISA, error) {
+	isa := rv.NewISA(csr.IsaExtS | csr.IsaExtU)
+	err := isa.Add(base)
+	if err != nil {
+		return nil, err
+	}
+	if addExt {
+		err = isa.Add(ext)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = isa.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return isa, nil
+}
+
 // newEmu32 returns a 32-bit emulator.
-func newEmu32() (*emuApp, error) {
+func newEmu32(ext bool) (*emuApp, error) {
 	// 32-bit ISA
-	isa := rv.NewISA(csr.IsaExtS | csr.IsaExtU)
-	err := isa.Add(rv.ISArv32gc)
+	isa, err := newISA(rv.ISArv32gc, rv.ISArv32bkv, ext)
 	if err != nil {
 		return nil, err
 	}
@@ -58,10 +78,9 @@ func newEmu32() (*emuApp, error) {
 }
 
 // newEmu64 returns a 64-bit emulator.
-func newEmu64() (*emuApp, error) {
+func newEmu64(ext bool) (*emuApp, error) {
 	// 64-bit ISA
-	isa := rv.NewISA(csr.IsaExtS | csr.IsaExtU)
-	err := isa.Add(rv.ISArv64gc)
+	isa, err := newISA(rv.ISArv64gc, rv.ISArv64bkv, ext)
 	if err != nil {
 		return nil, err
 	}
@@ -87,24 +106,52 @@ func (u *emuApp) Put(s string) {
 
 func main() {
 	// command line flags
-	fname := flag.String("f", "out.bin", "file to load (ELF)")
+	fname := flag.String("f", "out.bin", "file to load (ELF, binary, Intel HEX, S-Record)")
+	xlen := flag.Uint("x", 64, "cpu xlen (32 or 64) for non-ELF files")
+	base := flag.Uint64("b", 0x80000000, "load address for binary files")
+	endian := flag.String("endian", "le", "data byte order (le or be)")
+	jsonOut := flag.Bool("json", false, "write the disassembly of the loaded image to stdout as JSON and exit")
+	debug := flag.Bool("debug", false, "check the cpu state for consistency after each instruction")
+	ext := flag.Bool("ext", false, "add the bit manipulation, scalar crypto and vector extensions to the ISA")
 	flag.Parse()
 
-	elfClass, err := util.GetELFClass(*fname)
+	fileType, err := mem.GetFileType(*fname)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
+	bigEndian, err := mem.EndianArg(*endian)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	// work out the cpu class
+	var elfClass elf.Class
+	if fileType == mem.FileELF {
+		elfClass, err = util.GetELFClass(*fname)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	} else {
+		elfClass = map[uint]elf.Class{32: elf.ELFCLASS32, 64: elf.ELFCLASS64}[*xlen]
+	}
+
 	// create the application
 	var app *emuApp
 	switch elfClass {
 	case elf.ELFCLASS32:
-		app, err = newEmu32()
+		app, err = newEmu32(*ext)
 	case elf.ELFCLASS64:
-		app, err = newEmu64()
+		app, err = newEmu64(*ext)
 	default:
-		fmt.Fprintf(os.Stderr, "ELF class %d is not supported\n", elfClass)
+		if fileType == mem.FileELF {
+			fmt.Fprintf(os.Stderr, "ELF class %d is not supported\n", elfClass)
+		} else {
+			fmt.Fprintf(os.Stderr, "xlen %d is not supported\n", *xlen)
+		}
 		os.Exit(1)
 	}
 	if err != nil {
@@ -113,15 +160,41 @@ func main() {
 	}
 
 	// load the file
-	status, err := app.mem.LoadELF(*fname, app.elfClass)
+	app.mem.SetBigEndian(bigEndian)
+	var status string
+	switch fileType {
+	case mem.FileELF:
+		status, err = app.mem.LoadELF(*fname, app.elfClass)
+	case mem.FileIntelHex:
+		status, err = app.mem.LoadIntelHex(*fname, mem.AttrRWX)
+	case mem.FileSrec:
+		status, err = app.mem.LoadSrec(*fname, mem.AttrRWX)
+	default:
+		status, err = app.mem.LoadBinary(*fname, uint(*base), mem.AttrRWX)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 	fmt.Fprintf(os.Stderr, "%s\n", status)
 
+	// disassemble the executable regions
+	if *jsonOut {
+		da := []*rv.Disassembly{}
+		for _, x := range app.mem.Executable() {
+			da = append(da, app.cpu.DisassembleRange(x[0], x[1])...)
+		}
+		buf, err := json.MarshalIndent(da, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(append(buf, '\n'))
+		os.Exit(0)
+	}
+
 	// add a heap
-	app.mem.Add(mem.NewSection("heap", 0x80000000, heapSize, mem.AttrRW))
+	app.mem.AddBacking(app.mem.NewSection("heap", 0x80000000, heapSize, mem.AttrRW))
 
 	// Callback on the "tohost" write (compliance tests).
 	sym := app.mem.SymbolByName("tohost")
@@ -145,6 +218,7 @@ func main() {
 	c.SetPrompt(app.prompt)
 
 	// reset the cpu
+	app.cpu.SetDebug(*debug)
 	app.cpu.Reset()
 
 	// run the cli
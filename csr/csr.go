@@ -12,6 +12,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	cli "github.com/deadsy/go-cli"
 	"github.com/deadsy/riscv/util"
@@ -146,6 +147,7 @@ const (
 	SSTATUS = 0x100
 	SEDELEG = 0x102
 	SIDELEG = 0x103
+	SEPC    = 0x141
 	MSTATUS = 0x300
 	MEDELEG = 0x302
 	MIDELEG = 0x303
@@ -153,6 +155,12 @@ const (
 	MEPC    = 0x341
 	MCAUSE  = 0x342
 	MTVAL   = 0x343
+	TSELECT = 0x7a0
+	TDATA1  = 0x7a1
+	TDATA2  = 0x7a2
+	VL      = 0xc20
+	VTYPE   = 0xc21
+	VLENB   = 0xc22
 )
 
 //-----------------------------------------------------------------------------
@@ -402,6 +410,16 @@ func rdMEPC(s *State) uint {
 	return s.mepc
 }
 
+// GetSEPC returns the supervisor exception program counter.
+func (s *State) GetSEPC() uint {
+	return rdSEPC(s)
+}
+
+// GetMEPC returns the machine exception program counter.
+func (s *State) GetMEPC() uint {
+	return rdMEPC(s)
+}
+
 func (s *State) setEPC(pc uint64, mode Mode) {
 	epc := uint(pc & ^uint64(1))
 	switch mode {
@@ -733,6 +751,11 @@ func (s *State) GetMPP() Mode {
 	return Mode((s.mstatus.rd(ModeM) >> 11 /*MPP*/) & 3)
 }
 
+// GetSPP returns the SPP bit of mstatus.
+func (s *State) GetSPP() Mode {
+	return Mode(s.mstatusRdSPP())
+}
+
 func fmtXS(x uint) string {
 	return xsState(x).String()
 }
@@ -1096,6 +1119,25 @@ func (s *State) IncClockCycles(n uint) {
 	s.mcycle += uint64(n)
 }
 
+//-----------------------------------------------------------------------------
+// time
+
+// timeNow returns the real time counter (microseconds since the epoch).
+func timeNow() uint64 {
+	return uint64(time.Now().UnixNano() / 1000)
+}
+
+func rdTIME(s *State) uint {
+	if s.mxlen == 32 {
+		return uint(uint32(timeNow()))
+	}
+	return uint(timeNow())
+}
+
+func rdTIMEH(s *State) uint {
+	return uint(timeNow() >> 32)
+}
+
 //-----------------------------------------------------------------------------
 // minstret
 
@@ -1115,6 +1157,85 @@ func (s *State) IncInstructions() {
 	s.minstret++
 }
 
+//-----------------------------------------------------------------------------
+// vector
+
+// VLEN is the number of bits in a vector register.
+const VLEN = 128
+
+// VType is a decoded vector data type.
+type VType struct {
+	SEW   uint // selected element width (8, 16, 32 or 64 bits)
+	VLMUL uint // vector register group multiplier (0..3 = 1..8, 5..7 = 1/8..1/2)
+	TA    bool // tail agnostic
+	MA    bool // mask agnostic
+}
+
+// DecodeVType decodes a vtype value (nil if reserved or unsupported).
+func DecodeVType(vtype uint) *VType {
+	vlmul := vtype & 7
+	vsew := (vtype >> 3) & 7
+	if vtype>>8 != 0 || vsew > 3 || vlmul == 4 {
+		return nil
+	}
+	return &VType{
+		SEW:   8 << vsew,
+		VLMUL: vlmul,
+		TA:    (vtype>>6)&1 != 0,
+		MA:    (vtype>>7)&1 != 0,
+	}
+}
+
+func (vt *VType) String() string {
+	lmul := []string{"1", "2", "4", "8", "", "1/8", "1/4", "1/2"}[vt.VLMUL]
+	ta := []string{"tu", "ta"}[util.BoolToInt(vt.TA)]
+	ma := []string{"mu", "ma"}[util.BoolToInt(vt.MA)]
+	return fmt.Sprintf("SEW=%d, LMUL=%s, %s, %s", vt.SEW, lmul, ta, ma)
+}
+
+// vlmax returns the maximum vector length for a vtype (0 if unsupported).
+func vlmax(vtype uint) uint {
+	vt := DecodeVType(vtype)
+	if vt == nil {
+		return 0
+	}
+	if vt.VLMUL < 4 {
+		return (VLEN << vt.VLMUL) / vt.SEW
+	}
+	// fractional lmul
+	return (VLEN >> (8 - vt.VLMUL)) / vt.SEW
+}
+
+// SetVectorLength sets vtype, and vl from the application vector length.
+// It returns the new vector length.
+func (s *State) SetVectorLength(avl, vtype uint) uint {
+	n := vlmax(vtype)
+	if n == 0 {
+		// unsupported: set vill
+		s.vtype = 1 << (s.xlen - 1)
+		s.vl = 0
+		return 0
+	}
+	if avl > n {
+		avl = n
+	}
+	s.vtype = vtype
+	s.vl = avl
+	return s.vl
+}
+
+func rdVL(s *State) uint {
+	return s.vl
+}
+
+func rdVTYPE(s *State) uint {
+	return s.vtype
+}
+
+func rdVLENB(s *State) uint {
+	return VLEN / 8
+}
+
 //-----------------------------------------------------------------------------
 
 type wrFunc func(s *State, val uint)
@@ -1143,7 +1264,7 @@ var lookup = map[uint]csrDefn{
 	0x044: {"uip", wrUIP, rdUIP, nil},
 	// User CSRs 0xc00 - 0xc7f (read only)
 	0xc00: {"cycle", nil, rdMCYCLE, nil},
-	0xc01: {"time", nil, nil, nil},
+	0xc01: {"time", nil, rdTIME, nil},
 	0xc02: {"instret", nil, rdMINSTRET, nil},
 	0xc03: {"hpmcounter3", nil, nil, nil},
 	0xc04: {"hpmcounter4", nil, nil, nil},
@@ -1174,9 +1295,12 @@ var lookup = map[uint]csrDefn{
 	0xc1d: {"hpmcounter29", nil, nil, nil},
 	0xc1e: {"hpmcounter30", nil, nil, nil},
 	0xc1f: {"hpmcounter31", nil, nil, nil},
+	0xc20: {"vl", nil, rdVL, nil},
+	0xc21: {"vtype", nil, rdVTYPE, nil},
+	0xc22: {"vlenb", nil, rdVLENB, nil},
 	// User CSRs 0xc80 - 0xcbf (read only)
 	0xc80: {"cycleh", nil, rdMCYCLEH, nil},
-	0xc81: {"timeh", nil, nil, nil},
+	0xc81: {"timeh", nil, rdTIMEH, nil},
 	0xc82: {"instreth", nil, rdMINSTRETH, nil},
 	0xc83: {"hpmcounter3h", nil, nil, nil},
 	0xc84: {"hpmcounter4h", nil, nil, nil},
@@ -1219,12 +1343,15 @@ var lookup = map[uint]csrDefn{
 	0x142: {"scause", nil, rdSCAUSE, nil},
 	0x143: {"stval", wrSTVAL, rdSTVAL, nil},
 	0x144: {"sip", wrSIP, rdSIP, nil},
+	0x10a: {"senvcfg", nil, nil, nil},
+	0x5a8: {"scontext", nil, nil, nil},
 	0x180: {"satp", wrSATP, rdSATP, DisplaySATP},
 	// Machine CSRs 0xf00 - 0xf7f (read only)
 	0xf11: {"mvendorid", nil, rdZero, nil},
 	0xf12: {"marchid", nil, rdZero, nil},
 	0xf13: {"mimpid", nil, rdZero, nil},
 	0xf14: {"mhartid", nil, rdZero, nil},
+	0xf15: {"mconfigptr", nil, rdZero, nil},
 	// Machine CSRs 0x300 - 0x3ff (read/write)
 	0x300: {"mstatus", wrMSTATUS, rdMSTATUS, displayMSTATUS},
 	0x301: {"misa", wrMISA, rdMISA, displayMISA},
@@ -1233,6 +1360,9 @@ var lookup = map[uint]csrDefn{
 	0x304: {"mie", wrMIE, rdMIE, nil},
 	0x305: {"mtvec", wrMTVEC, rdMTVEC, displayMTVEC},
 	0x306: {"mcounteren", nil, nil, nil},
+	0x30a: {"menvcfg", nil, nil, nil},
+	0x310: {"mstatush", nil, nil, nil},
+	0x31a: {"menvcfgh", nil, nil, nil},
 	0x320: {"mucounteren", nil, nil, nil},
 	0x321: {"mscounteren", nil, nil, nil},
 	0x322: {"mhcounteren", nil, nil, nil},
@@ -1270,6 +1400,8 @@ var lookup = map[uint]csrDefn{
 	0x342: {"mcause", nil, rdMCAUSE, nil},
 	0x343: {"mtval", wrMTVAL, rdMTVAL, nil},
 	0x344: {"mip", wrMIP, rdMIP, nil},
+	0x34a: {"mtinst", nil, nil, nil},
+	0x34b: {"mtval2", nil, nil, nil},
 	0x380: {"mbase", nil, nil, nil},
 	0x381: {"mbound", nil, nil, nil},
 	0x382: {"mibase", nil, nil, nil},
@@ -1365,10 +1497,13 @@ var lookup = map[uint]csrDefn{
 	0x7a1: {"tdata1", wrIgnore, rdZero, nil},
 	0x7a2: {"tdata2", wrIgnore, rdZero, nil},
 	0x7a3: {"tdata3", wrIgnore, rdZero, nil},
+	0x7a5: {"tcontrol", nil, nil, nil},
+	0x7a8: {"mcontext", nil, nil, nil},
 	// Machine Debug Mode Only CSRs 0x7b0 - 0x7bf (read/write)
 	0x7b0: {"dcsr", nil, nil, nil},
 	0x7b1: {"dpc", nil, nil, nil},
-	0x7b2: {"dscratch", nil, nil, nil},
+	0x7b2: {"dscratch0", nil, nil, nil},
+	0x7b3: {"dscratch1", nil, nil, nil},
 	// Hypervisor CSRs 0x200 - 0x2ff (read/write)
 	0x200: {"hstatus", nil, nil, nil},
 	0x202: {"hedeleg", nil, nil, nil},
@@ -1439,6 +1574,8 @@ type State struct {
 	utval    uint // user trap value register
 	utvec    uint // user trap vector base address register
 	fcsr     uint // floating point control and status register
+	vl       uint // vector length
+	vtype    uint // vector data type register
 }
 
 // NewState returns a CSR state object.
@@ -1452,6 +1589,7 @@ func NewState(xlen, ext uint) *State {
 	}
 	initMISA(s, ext)
 	s.mstatus.init(s.mxlen)
+	s.vtype = 1 << (xlen - 1) // vill
 	return s
 }
 
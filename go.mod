@@ -1,5 +1,5 @@
 module github.com/deadsy/riscv
 
-go 1.13
+go 1.18
 
 require github.com/deadsy/go-cli v0.0.0-20191117003156-1fbe7fd20d78
@@ -11,6 +11,7 @@ package mem
 import (
 	"errors"
 	"strconv"
+	"strings"
 )
 
 //-----------------------------------------------------------------------------
@@ -34,3 +35,16 @@ func (m *Memory) AddrArg(arg string) (uint, error) {
 }
 
 //-----------------------------------------------------------------------------
+
+// EndianArg converts a byte order argument (le or be) to a big endian flag.
+func EndianArg(arg string) (bool, error) {
+	switch strings.ToLower(arg) {
+	case "le":
+		return false, nil
+	case "be":
+		return true, nil
+	}
+	return false, errors.New("invalid byte order, must be le or be")
+}
+
+//-----------------------------------------------------------------------------
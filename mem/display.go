@@ -44,6 +44,84 @@ func (m *Memory) Map() string {
 
 //-----------------------------------------------------------------------------
 
+// Layout returns a table of the memory regions sorted by start address.
+func (m *Memory) Layout() string {
+	if len(m.region) == 0 {
+		return "no regions"
+	}
+	// list of regions
+	regions := []*RegionInfo{}
+	for _, r := range m.region {
+		regions = append(regions, r.Info())
+	}
+	// sort by start address
+	sort.Sort(regionByStart(regions))
+	// display string
+	s := make([][]string, len(regions)+1)
+	s[0] = []string{"name", "start", "end", "size", "attr"}
+	for i, r := range regions {
+		startStr := m.AddrStr(r.start)
+		endStr := m.AddrStr(r.end)
+		sizeStr := fmt.Sprintf("0x%x", r.end-r.start+1)
+		s[i+1] = []string{r.name, startStr, endStr, sizeStr, r.attr.String()}
+	}
+	return cli.TableString(s, []int{0, 0, 0, 0, 0}, 1)
+}
+
+//-----------------------------------------------------------------------------
+
+// regionType returns the type name of a memory region.
+func regionType(r Region) string {
+	switch r.(type) {
+	case *Section, *SectionBE:
+		return "Chunk"
+	case *empty:
+		return "Empty"
+	}
+	return "MMIO"
+}
+
+// NewMemoryMap returns a table of the memory regions and the empty gaps
+// between them, sorted by start address.
+func (m *Memory) NewMemoryMap() string {
+	if len(m.region) == 0 {
+		return "no regions"
+	}
+	// list of regions
+	regions := []*RegionInfo{}
+	types := make(map[*RegionInfo]string)
+	for _, r := range m.region {
+		info := r.Info()
+		regions = append(regions, info)
+		types[info] = regionType(r)
+	}
+	// sort by start address
+	sort.Sort(regionByStart(regions))
+	// display string
+	s := [][]string{{"Start", "End", "Size", "Attr", "Type"}}
+	row := func(start, end uint, attr Attribute, t string) {
+		sizeStr := fmt.Sprintf("0x%x", end-start+1)
+		s = append(s, []string{m.AddrStr(start), m.AddrStr(end), sizeStr, attr.String(), t})
+	}
+	emptyAttr := m.noMemory.Info().attr
+	emptyType := regionType(m.noMemory)
+	var adr uint
+	for i, r := range regions {
+		if (i == 0 || adr != 0) && r.start > adr {
+			row(adr, r.start-1, emptyAttr, emptyType)
+		}
+		row(r.start, r.end, r.attr, types[r])
+		adr = r.end + 1
+	}
+	maxAdr := uint((1 << m.alen) - 1)
+	if adr != 0 && adr <= maxAdr {
+		row(adr, maxAdr, emptyAttr, emptyType)
+	}
+	return cli.TableString(s, []int{0, 0, 0, 0, 0}, 1)
+}
+
+//-----------------------------------------------------------------------------
+
 // Symbols returns an address sorted string of memory symbols.
 func (m *Memory) Symbols() string {
 	if len(m.symByName) == 0 {
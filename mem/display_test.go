@@ -0,0 +1,81 @@
+//-----------------------------------------------------------------------------
+/*
+
+Memory Display Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+// mmio is a memory mapped device region.
+type mmio struct {
+	*Section
+}
+
+// checkTable compares the whitespace separated fields of a display table.
+func checkTable(t *testing.T, s string, expect [][]string) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) != len(expect) {
+		t.Fatalf("%d lines (expected) %d lines (actual)", len(expect), len(lines))
+	}
+	for i, line := range lines {
+		if f := strings.Fields(line); strings.Join(f, " ") != strings.Join(expect[i], " ") {
+			t.Errorf("\"%s\" (expected) \"%s\" (actual)", strings.Join(expect[i], " "), strings.Join(f, " "))
+		}
+	}
+}
+
+func newTestMem(t *testing.T) *Memory {
+	m := NewMem32(nil, AttrR)
+	for _, r := range []Region{
+		NewSection("data", 0x2000, 0x1000, AttrRW),
+		NewSection("text", 0, 0x1000, AttrRX),
+		NewSectionBE("stack", 0xfffff000, 0x1000, AttrRW),
+		mmio{NewSection("uart", 0x10000000, 0x100, AttrRW)},
+	} {
+		err := m.Add(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func Test_Layout(t *testing.T) {
+	if s := NewMem32(nil, AttrR).Layout(); s != "no regions" {
+		t.Errorf("empty layout \"%s\"", s)
+	}
+	checkTable(t, newTestMem(t).Layout(), [][]string{
+		{"name", "start", "end", "size", "attr"},
+		{"text", "00000000", "00000fff", "0x1000", AttrRX.String()},
+		{"data", "00002000", "00002fff", "0x1000", AttrRW.String()},
+		{"uart", "10000000", "100000ff", "0x100", AttrRW.String()},
+		{"stack", "fffff000", "ffffffff", "0x1000", AttrRW.String()},
+	})
+}
+
+func Test_MemoryMap(t *testing.T) {
+	if s := NewMem32(nil, AttrR).NewMemoryMap(); s != "no regions" {
+		t.Errorf("empty map \"%s\"", s)
+	}
+	checkTable(t, newTestMem(t).NewMemoryMap(), [][]string{
+		{"Start", "End", "Size", "Attr", "Type"},
+		{"00000000", "00000fff", "0x1000", AttrRX.String(), "Chunk"},
+		{"00001000", "00001fff", "0x1000", AttrR.String(), "Empty"},
+		{"00002000", "00002fff", "0x1000", AttrRW.String(), "Chunk"},
+		{"00003000", "0fffffff", "0xfffd000", AttrR.String(), "Empty"},
+		{"10000000", "100000ff", "0x100", AttrRW.String(), "MMIO"},
+		{"10000100", "ffffefff", "0xefffef00", AttrR.String(), "Empty"},
+		{"fffff000", "ffffffff", "0x1000", AttrRW.String(), "Chunk"},
+	})
+}
+
+//-----------------------------------------------------------------------------
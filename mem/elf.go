@@ -38,14 +38,14 @@ func (m *Memory) loadSymbols(f *elf.File) string {
 
 //-----------------------------------------------------------------------------
 
-func makeSection(f *elf.File, s *elf.Section) (*Section, string) {
+func (m *Memory) makeSection(f *elf.File, s *elf.Section) (Region, string) {
 
 	if s.Size == 0 {
 		return nil, fmt.Sprintf("%s (0 bytes)", s.Name)
 	}
 
 	// create the memory section
-	ms := NewSection(s.Name, uint(s.Addr), uint(s.Size), AttrW)
+	ms := m.NewSection(s.Name, uint(s.Addr), uint(s.Size), AttrW)
 
 	if s.Type&elf.SHT_PROGBITS != 0 {
 		// read the section data from the ELF file
@@ -102,9 +102,12 @@ func (m *Memory) LoadELF(filename string, class elf.Class) (string, error) {
 	// load the sections
 	for _, fs := range f.Sections {
 		if fs.Flags&elf.SHF_ALLOC != 0 {
-			ms, status := makeSection(f, fs)
+			ms, status := m.makeSection(f, fs)
 			if ms != nil {
-				m.Add(ms)
+				err := m.Add(ms)
+				if err != nil {
+					status = fmt.Sprintf("can't add section %s (%s)", fs.Name, err)
+				}
 			}
 			s = append(s, status)
 		}
@@ -99,6 +99,14 @@ func pageError(va uint, attr Attribute) error {
 	return &Error{n, ex, va, ""}
 }
 
+// misaligned returns true if the address is not a multiple of the (power of 2) alignment.
+func misaligned(addr, align uint) bool {
+	if align == 0 || align&(align-1) != 0 {
+		panic(fmt.Sprintf("alignment %d is not a power of 2", align))
+	}
+	return addr&(align-1) != 0
+}
+
 func wrError(addr uint, attr Attribute, name string, align uint) error {
 	var n uint
 	ex := csr.ExUnknown10
@@ -106,7 +114,7 @@ func wrError(addr uint, attr Attribute, name string, align uint) error {
 		n |= ErrWrite
 		ex = csr.ExStoreAccessFault
 	}
-	if (attr&AttrM == 0) && (addr&(align-1) != 0) {
+	if misaligned(addr, align) && attr&AttrM == 0 {
 		n |= ErrAlign
 		ex = csr.ExStoreAddrMisaligned
 	}
@@ -123,7 +131,7 @@ func rdError(addr uint, attr Attribute, name string, align uint) error {
 		n |= ErrRead
 		ex = csr.ExLoadAccessFault
 	}
-	if (attr&AttrM == 0) && (addr&(align-1) != 0) {
+	if misaligned(addr, align) && attr&AttrM == 0 {
 		n |= ErrAlign
 		ex = csr.ExLoadAddrMisaligned
 	}
@@ -0,0 +1,58 @@
+//-----------------------------------------------------------------------------
+/*
+
+Memory Error Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Alignment(t *testing.T) {
+	for _, align := range []uint{1, 2, 4, 8} {
+		for adr := uint(0x1000); adr < 0x1010; adr++ {
+			expect := adr%align != 0
+			if err := rdError(adr, AttrR, "test", align); (err != nil) != expect {
+				t.Errorf("rd adr %x align %d: %v", adr, align, err)
+			}
+			if err := wrError(adr, AttrW, "test", align); (err != nil) != expect {
+				t.Errorf("wr adr %x align %d: %v", adr, align, err)
+			}
+			// misaligned access allowed
+			if err := rdError(adr, AttrR|AttrM, "test", align); err != nil {
+				t.Errorf("rd adr %x align %d: %v", adr, align, err)
+			}
+		}
+	}
+}
+
+func Test_BadAlignment(t *testing.T) {
+	check := func(name string, align uint, f func()) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				t.Errorf("%s align %d: no panic", name, align)
+			} else if !strings.Contains(fmt.Sprint(r), "not a power of 2") {
+				t.Errorf("%s align %d: panic \"%v\"", name, align, r)
+			}
+		}()
+		f()
+	}
+	for _, align := range []uint{0, 3, 6} {
+		check("rd", align, func() { rdError(0x1000, AttrR, "test", align) })
+		check("wr", align, func() { wrError(0x1000, AttrW, "test", align) })
+		// the alignment is checked even when misaligned access is allowed
+		check("rd misaligned", align, func() { rdError(0x1000, AttrR|AttrM, "test", align) })
+		check("wr misaligned", align, func() { wrError(0x1000, AttrW|AttrM, "test", align) })
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,335 @@
+//-----------------------------------------------------------------------------
+/*
+
+Binary, Intel HEX and Motorola S-Record Loaders
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// FileType is the type of a loadable file.
+type FileType int
+
+// File types.
+const (
+	FileBinary   FileType = iota // raw binary image
+	FileELF                      // ELF file
+	FileIntelHex                 // Intel HEX
+	FileSrec                     // Motorola S-Record
+)
+
+func (t FileType) String() string {
+	return [...]string{"binary", "elf", "ihex", "srec"}[t]
+}
+
+// GetFileType returns the type of a file by examining the file extension and contents.
+func GetFileType(filename string) (FileType, error) {
+	buf := make([]byte, 4)
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n, _ := f.Read(buf)
+	buf = buf[:n]
+	// magic bytes
+	if bytes.HasPrefix(buf, []byte("\x7fELF")) {
+		return FileELF, nil
+	}
+	// file extension
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".hex", ".ihex", ".ihx":
+		return FileIntelHex, nil
+	case ".srec", ".s19", ".s28", ".s37", ".mot":
+		return FileSrec, nil
+	case ".bin":
+		return FileBinary, nil
+	}
+	// text record formats
+	if len(buf) >= 2 && buf[0] == ':' && isHexDigit(buf[1]) {
+		return FileIntelHex, nil
+	}
+	if len(buf) >= 2 && buf[0] == 'S' && buf[1] >= '0' && buf[1] <= '9' {
+		return FileSrec, nil
+	}
+	return FileBinary, nil
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+//-----------------------------------------------------------------------------
+
+// block is a contiguous block of data to be loaded to memory.
+type block struct {
+	adr  uint
+	data []byte
+}
+
+// sort blocks by address
+type blockByAddr []*block
+
+func (a blockByAddr) Len() int           { return len(a) }
+func (a blockByAddr) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a blockByAddr) Less(i, j int) bool { return a[i].adr < a[j].adr }
+
+// addBlocks merges contiguous data blocks and adds them to memory as named sections.
+func (m *Memory) addBlocks(name string, blocks []*block, attr Attribute) (string, error) {
+	if len(blocks) == 0 {
+		return "", fmt.Errorf("%s has no data", name)
+	}
+	sort.Sort(blockByAddr(blocks))
+	// merge contiguous blocks
+	merged := []*block{}
+	for i, b := range blocks {
+		if i != 0 {
+			last := merged[len(merged)-1]
+			lastEnd := last.adr + uint(len(last.data))
+			if b.adr < lastEnd {
+				return "", fmt.Errorf("%s has overlapping data at %s", name, m.AddrStr(b.adr))
+			}
+			if b.adr == lastEnd {
+				last.data = append(last.data, b.data...)
+				continue
+			}
+		}
+		merged = append(merged, &block{b.adr, append([]byte{}, b.data...)})
+	}
+	// add the sections
+	s := []string{}
+	for i, b := range merged {
+		sname := name
+		if len(merged) > 1 {
+			sname = fmt.Sprintf("%s%d", name, i)
+		}
+		size := uint(len(b.data))
+		ms := m.NewSection(sname, b.adr, size, AttrW)
+		for j, v := range b.data {
+			ms.Wr8(b.adr+uint(j), v)
+		}
+		ms.SetAttr(attr)
+		err := m.Add(ms)
+		if err != nil {
+			return "", err
+		}
+		end := b.adr + size - 1
+		s = append(s, fmt.Sprintf("%-16s %08x-%08x %s (%d bytes)", sname, b.adr, end, attr.String(), size))
+	}
+	return strings.Join(s, "\n"), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// LoadBinary loads a raw binary image to memory at the base address.
+func (m *Memory) LoadBinary(filename string, adr uint, attr Attribute) (string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("%s is empty", filename)
+	}
+	s, err := m.addBlocks("binary", []*block{{adr, data}}, attr)
+	if err != nil {
+		return "", fmt.Errorf("%s %s", filename, err)
+	}
+	m.Entry = uint64(adr)
+	return s + fmt.Sprintf("\n%-16s %08x", "entry point", m.Entry), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// readRecords returns the hex record lines for a file, with the line numbers.
+func readRecords(filename string, start byte) ([]string, []int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	lines := []string{}
+	lineNumbers := []int{}
+	scanner := bufio.NewScanner(f)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] != start {
+			return nil, nil, fmt.Errorf("%s:%d record does not start with '%c'", filename, n, start)
+		}
+		lines = append(lines, line)
+		lineNumbers = append(lineNumbers, n)
+	}
+	return lines, lineNumbers, scanner.Err()
+}
+
+//-----------------------------------------------------------------------------
+
+// Intel HEX record types.
+const (
+	ihexData        = 0x00 // data
+	ihexEOF         = 0x01 // end of file
+	ihexExtSegAddr  = 0x02 // extended segment address
+	ihexStartSegAdr = 0x03 // start segment address (CS:IP)
+	ihexExtLinAddr  = 0x04 // extended linear address
+	ihexStartLinAdr = 0x05 // start linear address
+)
+
+// LoadIntelHex loads an Intel HEX file to memory.
+func (m *Memory) LoadIntelHex(filename string, attr Attribute) (string, error) {
+	lines, lineNumbers, err := readRecords(filename, ':')
+	if err != nil {
+		return "", err
+	}
+	var base uint
+	entry := m.Entry
+	blocks := []*block{}
+	eof := false
+	for i, line := range lines {
+		errPrefix := fmt.Sprintf("%s:%d", filename, lineNumbers[i])
+		buf, err := hex.DecodeString(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("%s %s", errPrefix, err)
+		}
+		if len(buf) < 5 || len(buf) != int(buf[0])+5 {
+			return "", fmt.Errorf("%s bad record length", errPrefix)
+		}
+		// checksum: all bytes sum to zero
+		var sum byte
+		for _, v := range buf {
+			sum += v
+		}
+		if sum != 0 {
+			return "", fmt.Errorf("%s checksum mismatch (expected %02x)", errPrefix, buf[len(buf)-1]-sum)
+		}
+		adr := uint(buf[1])<<8 | uint(buf[2])
+		data := buf[4 : len(buf)-1]
+		switch buf[3] {
+		case ihexData:
+			if len(data) != 0 {
+				blocks = append(blocks, &block{base + adr, data})
+			}
+		case ihexEOF:
+			eof = true
+		case ihexExtSegAddr:
+			if len(data) != 2 {
+				return "", fmt.Errorf("%s bad extended segment address record", errPrefix)
+			}
+			base = (uint(data[0])<<8 | uint(data[1])) << 4
+		case ihexStartSegAdr:
+			if len(data) != 4 {
+				return "", fmt.Errorf("%s bad start segment address record", errPrefix)
+			}
+			cs := uint(data[0])<<8 | uint(data[1])
+			ip := uint(data[2])<<8 | uint(data[3])
+			entry = uint64(cs<<4 + ip)
+		case ihexExtLinAddr:
+			if len(data) != 2 {
+				return "", fmt.Errorf("%s bad extended linear address record", errPrefix)
+			}
+			base = (uint(data[0])<<8 | uint(data[1])) << 16
+		case ihexStartLinAdr:
+			if len(data) != 4 {
+				return "", fmt.Errorf("%s bad start linear address record", errPrefix)
+			}
+			entry = uint64(uint(data[0])<<24 | uint(data[1])<<16 | uint(data[2])<<8 | uint(data[3]))
+		default:
+			return "", fmt.Errorf("%s unknown record type %02x", errPrefix, buf[3])
+		}
+		if eof {
+			break
+		}
+	}
+	if !eof {
+		return "", fmt.Errorf("%s has no end of file record", filename)
+	}
+	s, err := m.addBlocks("ihex", blocks, attr)
+	if err != nil {
+		return "", fmt.Errorf("%s %s", filename, err)
+	}
+	m.Entry = entry
+	return s + fmt.Sprintf("\n%-16s %08x", "entry point", m.Entry), nil
+}
+
+//-----------------------------------------------------------------------------
+
+// LoadSrec loads a Motorola S-Record file to memory.
+func (m *Memory) LoadSrec(filename string, attr Attribute) (string, error) {
+	lines, lineNumbers, err := readRecords(filename, 'S')
+	if err != nil {
+		return "", err
+	}
+	entry := m.Entry
+	blocks := []*block{}
+	for i, line := range lines {
+		errPrefix := fmt.Sprintf("%s:%d", filename, lineNumbers[i])
+		if len(line) < 2 {
+			return "", fmt.Errorf("%s bad record", errPrefix)
+		}
+		buf, err := hex.DecodeString(line[2:])
+		if err != nil {
+			return "", fmt.Errorf("%s %s", errPrefix, err)
+		}
+		if len(buf) < 2 || len(buf) != int(buf[0])+1 {
+			return "", fmt.Errorf("%s bad record length", errPrefix)
+		}
+		// checksum: ones complement of the sum of the count, address and data bytes
+		var sum byte
+		for _, v := range buf[:len(buf)-1] {
+			sum += v
+		}
+		if ^sum != buf[len(buf)-1] {
+			return "", fmt.Errorf("%s checksum mismatch (expected %02x)", errPrefix, ^sum)
+		}
+		// address length
+		alen := map[byte]int{'0': 2, '1': 2, '2': 3, '3': 4, '5': 2, '6': 3, '7': 4, '8': 3, '9': 2}[line[1]]
+		if alen == 0 {
+			return "", fmt.Errorf("%s unknown record type S%c", errPrefix, line[1])
+		}
+		if len(buf) < alen+2 {
+			return "", fmt.Errorf("%s bad record length", errPrefix)
+		}
+		var adr uint
+		for _, v := range buf[1 : 1+alen] {
+			adr = adr<<8 | uint(v)
+		}
+		data := buf[1+alen : len(buf)-1]
+		switch line[1] {
+		case '0', '5', '6':
+			// header and record counts: ignore
+		case '1', '2', '3':
+			if len(data) != 0 {
+				blocks = append(blocks, &block{adr, data})
+			}
+		case '7', '8', '9':
+			entry = uint64(adr)
+		}
+	}
+	s, err := m.addBlocks("srec", blocks, attr)
+	if err != nil {
+		return "", fmt.Errorf("%s %s", filename, err)
+	}
+	m.Entry = entry
+	return s + fmt.Sprintf("\n%-16s %08x", "entry point", m.Entry), nil
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,120 @@
+//-----------------------------------------------------------------------------
+/*
+
+Binary, Intel HEX and Motorola S-Record Loader Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_GetFileType(t *testing.T) {
+	testCases := []struct {
+		file  string
+		ftype FileType
+	}{
+		{"image.bin", FileBinary},
+		{"ihex.hex", FileIntelHex},
+		{"s19.srec", FileSrec},
+		{"elf.dat", FileELF},       // magic bytes
+		{"ihex.dat", FileIntelHex}, // record contents
+		{"srec.dat", FileSrec},     // record contents
+	}
+	for _, v := range testCases {
+		ftype, err := GetFileType(filepath.Join("testdata", v.file))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if ftype != v.ftype {
+			t.Errorf("%s: %s (expected) %s (actual)", v.file, v.ftype, ftype)
+		}
+	}
+	if _, err := GetFileType(filepath.Join("testdata", "missing.bin")); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+var loadProg = []byte{0x13, 0x05, 0x10, 0x00, 0x73, 0x00, 0x10, 0x00}
+
+func Test_Load(t *testing.T) {
+	testCases := []struct {
+		file   string
+		blocks []block // expected memory contents
+		entry  uint64  // expected entry point
+		err    string  // expected error
+	}{
+		// binary loaded at 0x80000000
+		{"image.bin", []block{{0x80000000, loadProg}}, 0x80000000, ""},
+		// intel hex: data, extended linear/segment address, start linear address, eof
+		{"ihex.hex", []block{
+			{0x80000000, loadProg},
+			{0x10010, []byte{0xaa, 0xbb, 0xcc, 0xdd}},
+		}, 0x80000004, ""},
+		// intel hex: start segment address
+		{"ihex_seg.hex", []block{{0x100, loadProg}}, 0x200, ""},
+		{"ihex_badsum.hex", nil, 0, "testdata/ihex_badsum.hex:1 checksum mismatch (expected 4d)"},
+		{"ihex_noeof.hex", nil, 0, "testdata/ihex_noeof.hex has no end of file record"},
+		// s-record: header, 16-bit data and entry
+		{"s19.srec", []block{{0x1000, loadProg}}, 0x1004, ""},
+		// s-record: 24-bit data and entry
+		{"s28.srec", []block{{0x12000, loadProg}}, 0x12000, ""},
+		// s-record: header, 32-bit data, count and entry
+		{"s37.srec", []block{
+			{0x80000000, loadProg},
+			{0x80001000, []byte{0xaa, 0xbb, 0xcc, 0xdd}},
+		}, 0x80000000, ""},
+		{"srec_badsum.srec", nil, 0, "testdata/srec_badsum.srec:1 checksum mismatch (expected 39)"},
+	}
+	for _, v := range testCases {
+		filename := filepath.Join("testdata", v.file)
+		m := NewMem32(nil, 0)
+		ftype, err := GetFileType(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch ftype {
+		case FileIntelHex:
+			_, err = m.LoadIntelHex(filename, AttrRX)
+		case FileSrec:
+			_, err = m.LoadSrec(filename, AttrRX)
+		default:
+			_, err = m.LoadBinary(filename, 0x80000000, AttrRX)
+		}
+		if v.err != "" {
+			if err == nil || !strings.Contains(err.Error(), v.err) {
+				t.Errorf("%s: \"%s\" (expected) \"%v\" (actual)", v.file, v.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", v.file, err)
+			continue
+		}
+		if m.Entry != v.entry {
+			t.Errorf("%s: entry %x (expected) %x (actual)", v.file, v.entry, m.Entry)
+		}
+		for _, b := range v.blocks {
+			buf := make([]byte, len(b.data))
+			for i := range buf {
+				buf[i], _ = m.Rd8Phys(b.adr + uint(i))
+			}
+			if !bytes.Equal(buf, b.data) {
+				t.Errorf("%s: %x: % x (expected) % x (actual)", v.file, b.adr, b.data, buf)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -10,6 +10,7 @@ package mem
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/deadsy/riscv/csr"
 )
@@ -27,6 +28,9 @@ type Memory struct {
 	symByAddr map[uint]*Symbol     // symbol table by address
 	symByName map[string]*Symbol   // symbol table by name
 	noMemory  Region               // empty memory region
+	watch     map[int]*watchPoint  // watch points by id
+	watchID   int                  // last watch point id
+	bigEndian bool                 // new sections have big endian data access
 }
 
 // newMemory returns a memory object.
@@ -39,6 +43,7 @@ func newMemory(alen uint, csr *csr.State, empty Attribute) *Memory {
 		symByAddr: make(map[uint]*Symbol),
 		symByName: make(map[string]*Symbol),
 		noMemory:  newEmpty(empty),
+		watch:     make(map[int]*watchPoint),
 	}
 }
 
@@ -64,9 +69,42 @@ func (m *Memory) AddrStr(addr uint) string {
 	return addrStr(addr, m.alen)
 }
 
+// overlap returns true if two regions have overlapping address ranges.
+func overlap(a, b *RegionInfo) bool {
+	return a.start <= b.end && b.start <= a.end
+}
+
 // Add a memory region to the memory.
-func (m *Memory) Add(r Region) {
+func (m *Memory) Add(r Region) error {
+	ri := r.Info()
+	for _, x := range m.region {
+		xi := x.Info()
+		if overlap(ri, xi) {
+			return fmt.Errorf("%s %s-%s overlaps %s %s-%s", ri.name, m.AddrStr(ri.start), m.AddrStr(ri.end), xi.name, m.AddrStr(xi.start), m.AddrStr(xi.end))
+		}
+	}
 	m.region = append(m.region, r)
+	return nil
+}
+
+// AddBacking adds a memory region without checking for overlaps.
+// The regions added before it take precedence, so it only backs the
+// addresses in its range that are not already mapped (e.g. a heap).
+func (m *Memory) AddBacking(r Region) {
+	m.region = append(m.region, r)
+}
+
+// SetBigEndian sets the data byte order for sections created by the memory.
+func (m *Memory) SetBigEndian(be bool) {
+	m.bigEndian = be
+}
+
+// NewSection returns a memory section with the data byte order of the memory.
+func (m *Memory) NewSection(name string, start, size uint, attr Attribute) Region {
+	if m.bigEndian {
+		return NewSectionBE(name, start, size, attr)
+	}
+	return NewSection(name, start, size, attr)
 }
 
 // findByName returns the memory region by name.
@@ -106,6 +144,23 @@ func (m *Memory) GetSectionName(adr uint) string {
 	return m.findByAddr(adr, 1).Info().name
 }
 
+// Executable returns the [start, end) address ranges of executable regions sorted by start address.
+func (m *Memory) Executable() [][2]uint {
+	regions := []*RegionInfo{}
+	for _, r := range m.region {
+		ri := r.Info()
+		if ri.attr&AttrX != 0 {
+			regions = append(regions, ri)
+		}
+	}
+	sort.Sort(regionByStart(regions))
+	x := make([][2]uint, len(regions))
+	for i, ri := range regions {
+		x[i] = [2]uint{ri.start, ri.end + 1}
+	}
+	return x
+}
+
 //-----------------------------------------------------------------------------
 // Physical Address Read Functions
 
@@ -156,6 +211,9 @@ func (m *Memory) Rd64(va uint) (uint64, error) {
 	}
 	val, err := m.Rd64Phys(pa)
 	m.monitor(pa, 8, AttrR)
+	if err == nil {
+		m.watchAccess(pa, 8, false, uint64(val))
+	}
 	return val, err
 }
 
@@ -167,6 +225,9 @@ func (m *Memory) Rd32(va uint) (uint32, error) {
 	}
 	val, err := m.Rd32Phys(pa)
 	m.monitor(pa, 4, AttrR)
+	if err == nil {
+		m.watchAccess(pa, 4, false, uint64(val))
+	}
 	return val, err
 }
 
@@ -178,6 +239,9 @@ func (m *Memory) Rd16(va uint) (uint16, error) {
 	}
 	val, err := m.Rd16Phys(pa)
 	m.monitor(pa, 2, AttrR)
+	if err == nil {
+		m.watchAccess(pa, 2, false, uint64(val))
+	}
 	return val, err
 }
 
@@ -189,9 +253,35 @@ func (m *Memory) Rd8(va uint) (uint8, error) {
 	}
 	val, err := m.Rd8Phys(pa)
 	m.monitor(pa, 1, AttrR)
+	if err == nil {
+		m.watchAccess(pa, 1, false, uint64(val))
+	}
 	return val, err
 }
 
+// RdDebug reads a width-bit data value from memory without triggering
+// any break points or watch points (for debugger use).
+func (m *Memory) RdDebug(va, width uint) (uint64, error) {
+	pa, err := m.va2pa(va, AttrR)
+	if err != nil {
+		return 0, err
+	}
+	switch width {
+	case 8:
+		val, err := m.Rd8Phys(pa)
+		return uint64(val), err
+	case 16:
+		val, err := m.Rd16Phys(pa)
+		return uint64(val), err
+	case 32:
+		val, err := m.Rd32Phys(pa)
+		return uint64(val), err
+	case 64:
+		return m.Rd64Phys(pa)
+	}
+	panic("bad width")
+}
+
 //-----------------------------------------------------------------------------
 // Physical Address Write Functions
 
@@ -226,6 +316,9 @@ func (m *Memory) Wr64(va uint, val uint64) error {
 	}
 	err = m.Wr64Phys(pa, val)
 	m.monitor(pa, 8, AttrW)
+	if err == nil {
+		m.watchAccess(pa, 8, true, uint64(val))
+	}
 	return err
 }
 
@@ -237,6 +330,9 @@ func (m *Memory) Wr32(va uint, val uint32) error {
 	}
 	err = m.Wr32Phys(pa, val)
 	m.monitor(pa, 4, AttrW)
+	if err == nil {
+		m.watchAccess(pa, 4, true, uint64(val))
+	}
 	return err
 }
 
@@ -248,6 +344,9 @@ func (m *Memory) Wr16(va uint, val uint16) error {
 	}
 	err = m.Wr16Phys(pa, val)
 	m.monitor(pa, 2, AttrW)
+	if err == nil {
+		m.watchAccess(pa, 2, true, uint64(val))
+	}
 	return err
 }
 
@@ -259,6 +358,9 @@ func (m *Memory) Wr8(va uint, val uint8) error {
 	}
 	err = m.Wr8Phys(pa, val)
 	m.monitor(pa, 1, AttrW)
+	if err == nil {
+		m.watchAccess(pa, 1, true, uint64(val))
+	}
 	return err
 }
 
@@ -0,0 +1,77 @@
+//-----------------------------------------------------------------------------
+/*
+
+Memory Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+func Test_Add(t *testing.T) {
+	testCases := []struct {
+		start, size uint
+		err         string
+	}{
+		{0x0000, 0x1000, ""}, // below
+		{0x5000, 0x1000, ""}, // above
+		{0x2000, 0x2000, "new 00002000-00003fff overlaps old 00002000-00003fff"}, // same
+		{0x1800, 0x1000, "new 00001800-000027ff overlaps old 00002000-00003fff"}, // start
+		{0x3800, 0x1000, "new 00003800-000047ff overlaps old 00002000-00003fff"}, // end
+		{0x2800, 0x0100, "new 00002800-000028ff overlaps old 00002000-00003fff"}, // inside
+		{0x1000, 0x4000, "new 00001000-00004fff overlaps old 00002000-00003fff"}, // around
+		{0x1000, 0x1000, ""}, // adjacent below
+		{0x4000, 0x1000, ""}, // adjacent above
+	}
+	for _, v := range testCases {
+		m := NewMem32(nil, 0)
+		err := m.Add(NewSection("old", 0x2000, 0x2000, AttrRW))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = m.Add(NewSection("new", v.start, v.size, AttrRW))
+		if v.err == "" && err != nil {
+			t.Errorf("%x-%x: unexpected error %s", v.start, v.size, err)
+		}
+		if v.err != "" && (err == nil || err.Error() != v.err) {
+			t.Errorf("%x-%x: \"%s\" (expected) \"%v\" (actual)", v.start, v.size, v.err, err)
+		}
+	}
+}
+
+func Test_AddBacking(t *testing.T) {
+	m := NewMem32(nil, 0)
+	for _, r := range []Region{
+		NewSection("text", 0x1000, 0x1000, AttrRX),
+		NewSection("data", 0x3000, 0x1000, AttrRW),
+	} {
+		err := m.Add(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.AddBacking(NewSection("heap", 0, 0x10000, AttrRW))
+	for _, v := range []struct {
+		adr  uint
+		name string
+	}{
+		{0x0000, "heap"},
+		{0x1000, "text"},
+		{0x2000, "heap"},
+		{0x3ffc, "data"},
+		{0x4000, "heap"},
+		{0x10000, "empty"},
+	} {
+		if name := m.GetSectionName(v.adr); name != v.name {
+			t.Errorf("%x: \"%s\" (expected) \"%s\" (actual)", v.adr, v.name, name)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
@@ -53,8 +53,8 @@ func (m *Section) Info() *RegionInfo {
 
 // In returns true if the adr, size is entirely within the memory chunk.
 func (m *Section) In(adr, size uint) bool {
-	end := adr + size - 1
-	return (adr >= m.start) && (end <= m.end)
+	// avoid overflow of adr + size at the top of the address space
+	return (adr >= m.start) && (adr <= m.end) && (size-1 <= m.end-adr)
 }
 
 // RdIns reads a 32-bit instruction from memory.
@@ -119,3 +119,58 @@ func (m *Section) Wr8(adr uint, val uint8) error {
 }
 
 //-----------------------------------------------------------------------------
+
+// SectionBE is a contiguous region of real memory with big endian data access.
+// Instructions are always little endian.
+type SectionBE struct {
+	*Section
+}
+
+// NewSectionBE allocates and returns a big endian memory chunk.
+func NewSectionBE(name string, start, size uint, attr Attribute) *SectionBE {
+	return &SectionBE{NewSection(name, start, size, attr)}
+}
+
+// Rd64 reads a 64-bit data value from memory.
+func (m *SectionBE) Rd64(adr uint) (uint64, error) {
+	return binary.BigEndian.Uint64(m.mem[adr-m.start:]), rdError(adr, m.attr, m.name, 8)
+}
+
+// Rd32 reads a 32-bit data value from memory.
+func (m *SectionBE) Rd32(adr uint) (uint32, error) {
+	return binary.BigEndian.Uint32(m.mem[adr-m.start:]), rdError(adr, m.attr, m.name, 4)
+}
+
+// Rd16 reads a 16-bit data value from memory.
+func (m *SectionBE) Rd16(adr uint) (uint16, error) {
+	return binary.BigEndian.Uint16(m.mem[adr-m.start:]), rdError(adr, m.attr, m.name, 2)
+}
+
+// Wr64 writes a 64-bit data value to memory.
+func (m *SectionBE) Wr64(adr uint, val uint64) error {
+	err := wrError(adr, m.attr, m.name, 8)
+	if err == nil {
+		binary.BigEndian.PutUint64(m.mem[adr-m.start:], val)
+	}
+	return err
+}
+
+// Wr32 writes a 32-bit data value to memory.
+func (m *SectionBE) Wr32(adr uint, val uint32) error {
+	err := wrError(adr, m.attr, m.name, 4)
+	if err == nil {
+		binary.BigEndian.PutUint32(m.mem[adr-m.start:], val)
+	}
+	return err
+}
+
+// Wr16 writes a 16-bit data value to memory.
+func (m *SectionBE) Wr16(adr uint, val uint16) error {
+	err := wrError(adr, m.attr, m.name, 2)
+	if err == nil {
+		binary.BigEndian.PutUint16(m.mem[adr-m.start:], val)
+	}
+	return err
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,169 @@
+//-----------------------------------------------------------------------------
+/*
+
+Memory Section Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"bytes"
+	"testing"
+)
+
+//-----------------------------------------------------------------------------
+
+// byteOrderTest writes known values and checks the raw memory bytes.
+func byteOrderTest(t *testing.T, r Region, buf []uint8) {
+	r.Wr64(0x1000, 0x0102030405060708)
+	r.Wr32(0x1008, 0x11121314)
+	r.Wr16(0x100c, 0x2122)
+	r.Wr8(0x100e, 0x31)
+	expect := map[bool][]uint8{
+		false: {8, 7, 6, 5, 4, 3, 2, 1, 0x14, 0x13, 0x12, 0x11, 0x22, 0x21, 0x31, 0},
+		true:  {1, 2, 3, 4, 5, 6, 7, 8, 0x11, 0x12, 0x13, 0x14, 0x21, 0x22, 0x31, 0},
+	}
+	_, be := r.(*SectionBE)
+	if !bytes.Equal(buf[:16], expect[be]) {
+		t.Errorf("be %v: % x (expected) % x (actual)", be, expect[be], buf[:16])
+	}
+	// read back
+	v64, _ := r.Rd64(0x1000)
+	v32, _ := r.Rd32(0x1008)
+	v16, _ := r.Rd16(0x100c)
+	v8, _ := r.Rd8(0x100e)
+	if v64 != 0x0102030405060708 || v32 != 0x11121314 || v16 != 0x2122 || v8 != 0x31 {
+		t.Errorf("be %v: bad read back %x %x %x %x", be, v64, v32, v16, v8)
+	}
+	// instructions are little endian
+	copy(buf[0x10:], []uint8{0x13, 0x05, 0x30, 0x00})
+	ins, _ := r.RdIns(0x1010)
+	if ins != 0x00300513 {
+		t.Errorf("be %v: instruction %08x (expected) %08x (actual)", be, 0x00300513, ins)
+	}
+}
+
+func Test_ByteOrder(t *testing.T) {
+	s := NewSection("le", 0x1000, 0x100, AttrRWX)
+	byteOrderTest(t, s, s.mem)
+	sbe := NewSectionBE("be", 0x1000, 0x100, AttrRWX)
+	byteOrderTest(t, sbe, sbe.mem)
+}
+
+func Test_MemoryByteOrder(t *testing.T) {
+	m := NewMem64(nil, 0)
+	if _, ok := m.NewSection("le", 0, 0x100, AttrRW).(*Section); !ok {
+		t.Errorf("expected a little endian section")
+	}
+	m.SetBigEndian(true)
+	if _, ok := m.NewSection("be", 0, 0x100, AttrRW).(*SectionBE); !ok {
+		t.Errorf("expected a big endian section")
+	}
+}
+
+// FuzzSectionAccess makes random accesses to memory containing a single section.
+func FuzzSectionAccess(f *testing.F) {
+	// start, size, attribute, address, operation, value
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRW), uint64(0x1000), uint8(0), uint64(0))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRW), uint64(0x10f8), uint8(3), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRW), uint64(0x10f9), uint8(3), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRWM), uint64(0x10fe), uint8(6), uint64(0xffff))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRW), uint64(0xfff), uint8(4), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRW), uint64(0x1100), uint8(7), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrR), uint64(0x1000), uint8(5), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrW), uint64(0x1000), uint8(1), uint64(1))
+	f.Add(uint64(0x1000), uint16(0x100), uint8(AttrRX), uint64(0x1002), uint8(8), uint64(1))
+	f.Add(uint64(0xfffffffffff00), uint16(0xffff), uint8(AttrRWX), uint64(0xffffffffffffffff), uint8(3), uint64(1))
+	f.Add(uint64(0), uint16(0), uint8(AttrRWM), uint64(0), uint8(4), uint64(1))
+	f.Fuzz(func(t *testing.T, start64 uint64, size16 uint16, attr8 uint8, adr64 uint64, op uint8, val uint64) {
+		start := uint(start64 % (1 << 52))
+		size := uint(size16) + 1
+		attr := Attribute(attr8) & (AttrRWX | AttrM)
+		adr := uint(adr64)
+		m := NewMem64(nil, 0)
+		s := NewSection("test", start, size, attr)
+		err := m.Add(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// operation: access size and type
+		n := [...]uint{1, 2, 4, 8, 1, 2, 4, 8, 4}[op%9]
+		isWrite := op%9 >= 4 && op%9 < 8
+		isIns := op%9 == 8
+		switch op % 9 {
+		case 0:
+			var x uint8
+			x, err = m.Rd8Phys(adr)
+			val = uint64(x)
+		case 1:
+			var x uint16
+			x, err = m.Rd16Phys(adr)
+			val = uint64(x)
+		case 2:
+			var x uint32
+			x, err = m.Rd32Phys(adr)
+			val = uint64(x)
+		case 3:
+			val, err = m.Rd64Phys(adr)
+		case 4:
+			err = m.Wr8Phys(adr, uint8(val))
+		case 5:
+			err = m.Wr16Phys(adr, uint16(val))
+		case 6:
+			err = m.Wr32Phys(adr, uint32(val))
+		case 7:
+			err = m.Wr64Phys(adr, val)
+		case 8:
+			var x uint
+			x, err = m.RdInsPhys(adr)
+			val = uint64(x)
+		}
+		inside := adr >= start && adr <= s.end && n-1 <= s.end-adr
+		if !inside {
+			// out of bounds accesses go to the empty region
+			if err == nil || err.(*Error).Type&ErrEmpty == 0 {
+				t.Fatalf("%x/%d outside of %x-%x: expected an empty memory error (%v)", adr, n, start, s.end, err)
+			}
+			return
+		}
+		// attribute violations
+		align := n
+		if isIns {
+			align = 2
+		}
+		var expect uint
+		if attr&AttrM == 0 && adr&(align-1) != 0 {
+			expect |= ErrAlign
+		}
+		switch {
+		case isIns && attr&AttrX == 0:
+			expect |= ErrExec
+		case isWrite && attr&AttrW == 0:
+			expect |= ErrWrite
+		case !isWrite && !isIns && attr&AttrR == 0:
+			expect |= ErrRead
+		}
+		if expect == 0 {
+			if err != nil {
+				t.Fatalf("%x/%d attr %s: unexpected error %s", adr, n, attr, err)
+			}
+		} else {
+			if err == nil || err.(*Error).Type != expect {
+				t.Fatalf("%x/%d attr %s: expected error type %x (%v)", adr, n, attr, expect, err)
+			}
+			return
+		}
+		// check the written bytes
+		if isWrite {
+			for i := uint(0); i < n; i++ {
+				if s.mem[adr-start+i] != uint8(val>>(8*i)) {
+					t.Fatalf("%x/%d: bad write of %x", adr, n, val)
+				}
+			}
+		}
+	})
+}
+
+//-----------------------------------------------------------------------------
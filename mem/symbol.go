@@ -34,6 +34,35 @@ func (m *Memory) SymbolByAddress(adr uint) *Symbol {
 	return m.symByAddr[adr]
 }
 
+// SymbolContaining returns the symbol with an address range containing the address.
+func (m *Memory) SymbolContaining(adr uint) *Symbol {
+	if s := m.symByAddr[adr]; s != nil {
+		return s
+	}
+	var symbol *Symbol
+	for _, s := range m.symByAddr {
+		if adr >= s.Addr && adr < s.Addr+s.Size {
+			if symbol == nil || s.Addr > symbol.Addr {
+				symbol = s
+			}
+		}
+	}
+	return symbol
+}
+
+// SymbolNearest returns the closest symbol at or below the address within the maximum offset.
+func (m *Memory) SymbolNearest(adr, maxOffset uint) *Symbol {
+	var symbol *Symbol
+	for _, s := range m.symByAddr {
+		if adr >= s.Addr && adr-s.Addr < maxOffset {
+			if symbol == nil || s.Addr > symbol.Addr {
+				symbol = s
+			}
+		}
+	}
+	return symbol
+}
+
 // SymbolByName returns the symbol for a symbol name.
 func (m *Memory) SymbolByName(s string) *Symbol {
 	return m.symByName[s]
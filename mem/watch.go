@@ -0,0 +1,121 @@
+//-----------------------------------------------------------------------------
+/*
+
+Memory Watch Points
+
+Call a function when a memory range has read/write data access.
+
+*/
+//-----------------------------------------------------------------------------
+
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// WatchMode is the access type that triggers a watch point.
+type WatchMode uint
+
+// Watch point modes.
+const (
+	WatchRead      WatchMode = 1 << iota // trigger on read
+	WatchWrite                           // trigger on write
+	WatchReadWrite = WatchRead | WatchWrite
+)
+
+func (w WatchMode) String() string {
+	return [...]string{"-", "r", "w", "rw"}[w&WatchReadWrite]
+}
+
+// WatchModeArg converts a watch mode argument to a watch mode.
+func WatchModeArg(arg string) (WatchMode, error) {
+	mode, ok := map[string]WatchMode{"r": WatchRead, "w": WatchWrite, "rw": WatchReadWrite}[strings.ToLower(arg)]
+	if !ok {
+		return 0, errors.New("invalid watch mode, must be r, w or rw")
+	}
+	return mode, nil
+}
+
+// WatchFunc is called when a watch point is triggered.
+type WatchFunc func(adr, size uint, isWrite bool, val uint64)
+
+type watchPoint struct {
+	id   int       // watch point identifier
+	adr  uint      // start address
+	size uint      // size in bytes
+	mode WatchMode // access for trigger
+	fn   WatchFunc // callback function
+}
+
+//-----------------------------------------------------------------------------
+
+// SetWatch adds a watch point for an address range and returns its identifier.
+func (m *Memory) SetWatch(adr, size uint, mode WatchMode, fn WatchFunc) int {
+	m.watchID++
+	m.watch[m.watchID] = &watchPoint{m.watchID, adr, size, mode, fn}
+	return m.watchID
+}
+
+// ClearWatch removes a watch point.
+func (m *Memory) ClearWatch(id int) error {
+	if _, ok := m.watch[id]; !ok {
+		return fmt.Errorf("watch point %d not found", id)
+	}
+	delete(m.watch, id)
+	return nil
+}
+
+// watchAccess calls the functions of watch points overlapping the accessed range.
+func (m *Memory) watchAccess(adr, size uint, isWrite bool, val uint64) {
+	if len(m.watch) == 0 {
+		return
+	}
+	mode := WatchRead
+	if isWrite {
+		mode = WatchWrite
+	}
+	for _, w := range m.watchList() {
+		if w.mode&mode != 0 && adr < w.adr+w.size && w.adr < adr+size {
+			w.fn(adr, size, isWrite, val)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// sort watch points by identifier
+type watchByID []*watchPoint
+
+func (a watchByID) Len() int           { return len(a) }
+func (a watchByID) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a watchByID) Less(i, j int) bool { return a[i].id < a[j].id }
+
+// watchList returns the watch points sorted by identifier.
+func (m *Memory) watchList() []*watchPoint {
+	x := []*watchPoint{}
+	for _, w := range m.watch {
+		x = append(x, w)
+	}
+	sort.Sort(watchByID(x))
+	return x
+}
+
+// DisplayWatches returns a display string for the memory watch points.
+func (m *Memory) DisplayWatches() string {
+	if len(m.watch) == 0 {
+		return "no watch points"
+	}
+	s := []string{}
+	for _, w := range m.watchList() {
+		s = append(s, fmt.Sprintf("%d: %s %d %s", w.id, m.AddrStr(w.adr), w.size, w.mode))
+	}
+	return strings.Join(s, "\n")
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,97 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Call Stack Backtrace
+
+Walk the frame pointer chain of the standard RISC-V frame layout.
+The return address is saved at fp-xlen/8 and the caller's frame pointer at fp-2*xlen/8.
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import (
+	"fmt"
+	"strings"
+)
+
+//-----------------------------------------------------------------------------
+
+// symbolRange is the maximum offset from a symbol for a frame to be named by it.
+const symbolRange = 0x1000
+
+// Frame is a call stack frame.
+type Frame struct {
+	PC       uint   // program counter
+	SP       uint   // stack pointer
+	FuncName string // function name (if known)
+	Offset   uint   // offset of the PC from the function start
+}
+
+func (f *Frame) String() string {
+	if f.FuncName == "" {
+		return fmt.Sprintf("0x%x in ??", f.PC)
+	}
+	return fmt.Sprintf("0x%x in %s+0x%x", f.PC, f.FuncName, f.Offset)
+}
+
+// newFrame returns a frame with the function name resolved from the symbol table.
+func (m *RV) newFrame(pc, sp uint) Frame {
+	f := Frame{PC: pc, SP: sp}
+	s := m.Mem.SymbolContaining(pc)
+	if s == nil {
+		s = m.Mem.SymbolNearest(pc, symbolRange)
+	}
+	if s != nil {
+		f.FuncName = s.Name
+		f.Offset = pc - s.Addr
+	}
+	return f
+}
+
+// rdXLEN reads an xlen sized value from memory (without triggering memory monitors).
+func (m *RV) rdXLEN(adr uint) (uint, error) {
+	val, err := m.Mem.RdDebug(adr, m.xlen)
+	return uint(val), err
+}
+
+// Backtrace returns up to maxDepth call stack frames, starting with the current PC.
+func (m *RV) Backtrace(maxDepth int) []Frame {
+	frames := []Frame{}
+	if maxDepth <= 0 {
+		return frames
+	}
+	frames = append(frames, m.newFrame(uint(m.PC), uint(m.rdX(RegSp))))
+	n := m.xlen >> 3
+	fp := uint(m.rdX(RegS0))
+	for len(frames) < maxDepth && fp != 0 {
+		ra, err := m.rdXLEN(fp - n)
+		if err != nil || ra == 0 {
+			break
+		}
+		prev, err := m.rdXLEN(fp - 2*n)
+		if err != nil {
+			break
+		}
+		// the caller's stack pointer is the callee's frame pointer
+		frames = append(frames, m.newFrame(ra, fp))
+		fp = prev
+	}
+	return frames
+}
+
+// DisplayBacktrace returns a display string for the call stack backtrace.
+func (m *RV) DisplayBacktrace(maxDepth int) string {
+	frames := m.Backtrace(maxDepth)
+	if len(frames) == 0 {
+		return "no stack"
+	}
+	s := make([]string, len(frames))
+	for i := range frames {
+		s[i] = fmt.Sprintf("#%-2d %s (sp 0x%x)", i, frames[i].String(), frames[i].SP)
+	}
+	return strings.Join(s, "\n")
+}
+
+//-----------------------------------------------------------------------------
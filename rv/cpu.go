@@ -55,6 +55,18 @@ func decodeR(ins uint) (uint, uint, uint, uint) {
 	return rs2, rs1, rm, rd
 }
 
+func decodeRa(ins uint) (uint, uint, uint, uint) {
+	bs := bitUnsigned(ins, 31, 30, 0)
+	rs2, rs1, _, rd := decodeR(ins)
+	return bs, rs2, rs1, rd
+}
+
+func decodeRb(ins uint) (uint, uint, uint, uint) {
+	vm := bitUnsigned(ins, 25, 25, 0)
+	rs2, rs1, _, rd := decodeR(ins)
+	return vm, rs2, rs1, rd
+}
+
 func decodeR4(ins uint) (uint, uint, uint, uint, uint) {
 	rs3 := bitUnsigned(ins, 31, 27, 0)
 	rs2 := bitUnsigned(ins, 24, 20, 0)
@@ -91,6 +103,34 @@ func decodeId(ins uint) (uint, uint) {
 	return rs2, rs1
 }
 
+func decodeIe(ins uint) (uint, uint, uint) {
+	rnum := bitUnsigned(ins, 23, 20, 0)
+	rs1 := bitUnsigned(ins, 19, 15, 0)
+	rd := bitUnsigned(ins, 11, 7, 0)
+	return rnum, rs1, rd
+}
+
+func decodeIf(ins uint) (uint, uint, uint) {
+	vtypei := bitUnsigned(ins, 30, 20, 0)
+	rs1 := bitUnsigned(ins, 19, 15, 0)
+	rd := bitUnsigned(ins, 11, 7, 0)
+	return vtypei, rs1, rd
+}
+
+func decodeIg(ins uint) (uint, uint, uint) {
+	vtypei := bitUnsigned(ins, 29, 20, 0)
+	uimm := bitUnsigned(ins, 19, 15, 0)
+	rd := bitUnsigned(ins, 11, 7, 0)
+	return vtypei, uimm, rd
+}
+
+func decodeIh(ins uint) (uint, uint, uint) {
+	vm := bitUnsigned(ins, 25, 25, 0)
+	rs1 := bitUnsigned(ins, 19, 15, 0)
+	vd := bitUnsigned(ins, 11, 7, 0)
+	return vm, rs1, vd
+}
+
 func decodeS(ins uint) (int, uint, uint) {
 	uimm := bitUnsigned(ins, 31, 25, 5) // imm[11:5]
 	uimm += bitUnsigned(ins, 11, 7, 0)  // imm[4:0]
@@ -0,0 +1,228 @@
+//-----------------------------------------------------------------------------
+/*
+
+Scalar Cryptography Utilities
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import "math/bits"
+
+//-----------------------------------------------------------------------------
+// AES
+
+// aesSbox is the AES forward S-box.
+var aesSbox = [256]uint8{
+	0x63, 0x7c, 0x77, 0x7b, 0xf2, 0x6b, 0x6f, 0xc5, 0x30, 0x01, 0x67, 0x2b, 0xfe, 0xd7, 0xab, 0x76,
+	0xca, 0x82, 0xc9, 0x7d, 0xfa, 0x59, 0x47, 0xf0, 0xad, 0xd4, 0xa2, 0xaf, 0x9c, 0xa4, 0x72, 0xc0,
+	0xb7, 0xfd, 0x93, 0x26, 0x36, 0x3f, 0xf7, 0xcc, 0x34, 0xa5, 0xe5, 0xf1, 0x71, 0xd8, 0x31, 0x15,
+	0x04, 0xc7, 0x23, 0xc3, 0x18, 0x96, 0x05, 0x9a, 0x07, 0x12, 0x80, 0xe2, 0xeb, 0x27, 0xb2, 0x75,
+	0x09, 0x83, 0x2c, 0x1a, 0x1b, 0x6e, 0x5a, 0xa0, 0x52, 0x3b, 0xd6, 0xb3, 0x29, 0xe3, 0x2f, 0x84,
+	0x53, 0xd1, 0x00, 0xed, 0x20, 0xfc, 0xb1, 0x5b, 0x6a, 0xcb, 0xbe, 0x39, 0x4a, 0x4c, 0x58, 0xcf,
+	0xd0, 0xef, 0xaa, 0xfb, 0x43, 0x4d, 0x33, 0x85, 0x45, 0xf9, 0x02, 0x7f, 0x50, 0x3c, 0x9f, 0xa8,
+	0x51, 0xa3, 0x40, 0x8f, 0x92, 0x9d, 0x38, 0xf5, 0xbc, 0xb6, 0xda, 0x21, 0x10, 0xff, 0xf3, 0xd2,
+	0xcd, 0x0c, 0x13, 0xec, 0x5f, 0x97, 0x44, 0x17, 0xc4, 0xa7, 0x7e, 0x3d, 0x64, 0x5d, 0x19, 0x73,
+	0x60, 0x81, 0x4f, 0xdc, 0x22, 0x2a, 0x90, 0x88, 0x46, 0xee, 0xb8, 0x14, 0xde, 0x5e, 0x0b, 0xdb,
+	0xe0, 0x32, 0x3a, 0x0a, 0x49, 0x06, 0x24, 0x5c, 0xc2, 0xd3, 0xac, 0x62, 0x91, 0x95, 0xe4, 0x79,
+	0xe7, 0xc8, 0x37, 0x6d, 0x8d, 0xd5, 0x4e, 0xa9, 0x6c, 0x56, 0xf4, 0xea, 0x65, 0x7a, 0xae, 0x08,
+	0xba, 0x78, 0x25, 0x2e, 0x1c, 0xa6, 0xb4, 0xc6, 0xe8, 0xdd, 0x74, 0x1f, 0x4b, 0xbd, 0x8b, 0x8a,
+	0x70, 0x3e, 0xb5, 0x66, 0x48, 0x03, 0xf6, 0x0e, 0x61, 0x35, 0x57, 0xb9, 0x86, 0xc1, 0x1d, 0x9e,
+	0xe1, 0xf8, 0x98, 0x11, 0x69, 0xd9, 0x8e, 0x94, 0x9b, 0x1e, 0x87, 0xe9, 0xce, 0x55, 0x28, 0xdf,
+	0x8c, 0xa1, 0x89, 0x0d, 0xbf, 0xe6, 0x42, 0x68, 0x41, 0x99, 0x2d, 0x0f, 0xb0, 0x54, 0xbb, 0x16,
+}
+
+// aesInvSbox is the AES inverse S-box.
+var aesInvSbox = [256]uint8{
+	0x52, 0x09, 0x6a, 0xd5, 0x30, 0x36, 0xa5, 0x38, 0xbf, 0x40, 0xa3, 0x9e, 0x81, 0xf3, 0xd7, 0xfb,
+	0x7c, 0xe3, 0x39, 0x82, 0x9b, 0x2f, 0xff, 0x87, 0x34, 0x8e, 0x43, 0x44, 0xc4, 0xde, 0xe9, 0xcb,
+	0x54, 0x7b, 0x94, 0x32, 0xa6, 0xc2, 0x23, 0x3d, 0xee, 0x4c, 0x95, 0x0b, 0x42, 0xfa, 0xc3, 0x4e,
+	0x08, 0x2e, 0xa1, 0x66, 0x28, 0xd9, 0x24, 0xb2, 0x76, 0x5b, 0xa2, 0x49, 0x6d, 0x8b, 0xd1, 0x25,
+	0x72, 0xf8, 0xf6, 0x64, 0x86, 0x68, 0x98, 0x16, 0xd4, 0xa4, 0x5c, 0xcc, 0x5d, 0x65, 0xb6, 0x92,
+	0x6c, 0x70, 0x48, 0x50, 0xfd, 0xed, 0xb9, 0xda, 0x5e, 0x15, 0x46, 0x57, 0xa7, 0x8d, 0x9d, 0x84,
+	0x90, 0xd8, 0xab, 0x00, 0x8c, 0xbc, 0xd3, 0x0a, 0xf7, 0xe4, 0x58, 0x05, 0xb8, 0xb3, 0x45, 0x06,
+	0xd0, 0x2c, 0x1e, 0x8f, 0xca, 0x3f, 0x0f, 0x02, 0xc1, 0xaf, 0xbd, 0x03, 0x01, 0x13, 0x8a, 0x6b,
+	0x3a, 0x91, 0x11, 0x41, 0x4f, 0x67, 0xdc, 0xea, 0x97, 0xf2, 0xcf, 0xce, 0xf0, 0xb4, 0xe6, 0x73,
+	0x96, 0xac, 0x74, 0x22, 0xe7, 0xad, 0x35, 0x85, 0xe2, 0xf9, 0x37, 0xe8, 0x1c, 0x75, 0xdf, 0x6e,
+	0x47, 0xf1, 0x1a, 0x71, 0x1d, 0x29, 0xc5, 0x89, 0x6f, 0xb7, 0x62, 0x0e, 0xaa, 0x18, 0xbe, 0x1b,
+	0xfc, 0x56, 0x3e, 0x4b, 0xc6, 0xd2, 0x79, 0x20, 0x9a, 0xdb, 0xc0, 0xfe, 0x78, 0xcd, 0x5a, 0xf4,
+	0x1f, 0xdd, 0xa8, 0x33, 0x88, 0x07, 0xc7, 0x31, 0xb1, 0x12, 0x10, 0x59, 0x27, 0x80, 0xec, 0x5f,
+	0x60, 0x51, 0x7f, 0xa9, 0x19, 0xb5, 0x4a, 0x0d, 0x2d, 0xe5, 0x7a, 0x9f, 0x93, 0xc9, 0x9c, 0xef,
+	0xa0, 0xe0, 0x3b, 0x4d, 0xae, 0x2a, 0xf5, 0xb0, 0xc8, 0xeb, 0xbb, 0x3c, 0x83, 0x53, 0x99, 0x61,
+	0x17, 0x2b, 0x04, 0x7e, 0xba, 0x77, 0xd6, 0x26, 0xe1, 0x69, 0x14, 0x63, 0x55, 0x21, 0x0c, 0x7d,
+}
+
+// aesXtime multiplies by x in GF(2^8).
+func aesXtime(a uint8) uint8 {
+	if a&0x80 != 0 {
+		return (a << 1) ^ 0x1b
+	}
+	return a << 1
+}
+
+// aesMul multiplies a by b in GF(2^8).
+func aesMul(a, b uint8) uint8 {
+	var x uint8
+	for ; b != 0; b >>= 1 {
+		if b&1 != 0 {
+			x ^= a
+		}
+		a = aesXtime(a)
+	}
+	return x
+}
+
+// aesMixFwd returns the MixColumns contribution of a byte as a column word.
+func aesMixFwd(b uint8) uint32 {
+	return uint32(aesMul(b, 3))<<24 | uint32(b)<<16 | uint32(b)<<8 | uint32(aesMul(b, 2))
+}
+
+// aesMixInv returns the InvMixColumns contribution of a byte as a column word.
+func aesMixInv(b uint8) uint32 {
+	return uint32(aesMul(b, 11))<<24 | uint32(aesMul(b, 13))<<16 | uint32(aesMul(b, 9))<<8 | uint32(aesMul(b, 14))
+}
+
+// aes32 applies the S-box (and optionally mix) to byte bs of rs2 and xors the
+// result (rotated back into the byte position) with rs1.
+func aes32(rs1, rs2 uint32, bs uint, sbox *[256]uint8, mix func(uint8) uint32) uint32 {
+	shamt := int(bs << 3)
+	x := uint32(sbox[uint8(rs2>>shamt)])
+	if mix != nil {
+		x = mix(uint8(x))
+	}
+	return rs1 ^ bits.RotateLeft32(x, shamt)
+}
+
+// aesRcon is the AES key schedule round constants.
+var aesRcon = [10]uint8{0x01, 0x02, 0x04, 0x08, 0x10, 0x20, 0x40, 0x80, 0x1b, 0x36}
+
+// aesSubWord applies the S-box to each byte of a word.
+func aesSubWord(x uint32) uint32 {
+	var y uint32
+	for i := 0; i < 32; i += 8 {
+		y |= uint32(aesSbox[uint8(x>>i)]) << i
+	}
+	return y
+}
+
+// aesInvMixColumn applies InvMixColumns to a column word.
+func aesInvMixColumn(x uint32) uint32 {
+	var y uint32
+	for i := 0; i < 32; i += 8 {
+		y ^= bits.RotateLeft32(aesMixInv(uint8(x>>i)), i)
+	}
+	return y
+}
+
+// aes64ks1i returns the substituted (and rotated) high word of rs1 xored with
+// the round constant, in both halves. rnum == 10 skips the rotation and constant.
+func aes64ks1i(rs1 uint64, rnum uint) uint64 {
+	x := uint32(rs1 >> 32)
+	var rc uint32
+	if rnum != 10 {
+		x = bits.RotateLeft32(x, -8)
+		rc = uint32(aesRcon[rnum])
+	}
+	x = aesSubWord(x) ^ rc
+	return uint64(x)<<32 | uint64(x)
+}
+
+// aes64ks2 returns the next two round key words.
+func aes64ks2(rs1, rs2 uint64) uint64 {
+	w0 := uint32(rs1>>32) ^ uint32(rs2)
+	w1 := w0 ^ uint32(rs2>>32)
+	return uint64(w1)<<32 | uint64(w0)
+}
+
+// aes64im applies InvMixColumns to both column words.
+func aes64im(rs1 uint64) uint64 {
+	return uint64(aesInvMixColumn(uint32(rs1>>32)))<<32 | uint64(aesInvMixColumn(uint32(rs1)))
+}
+
+//-----------------------------------------------------------------------------
+// SHA-512 (RV32)
+// Each 64-bit function is computed as two 32-bit halves.
+// rs1 is the half being computed and rs2 is the other half.
+
+func sha512sig0h(rs1, rs2 uint32) uint32 {
+	return rs1>>1 ^ rs1>>7 ^ rs1>>8 ^ rs2<<31 ^ rs2<<24
+}
+
+func sha512sig0l(rs1, rs2 uint32) uint32 {
+	return rs1>>1 ^ rs1>>7 ^ rs1>>8 ^ rs2<<31 ^ rs2<<25 ^ rs2<<24
+}
+
+func sha512sig1h(rs1, rs2 uint32) uint32 {
+	return rs1<<3 ^ rs1>>6 ^ rs1>>19 ^ rs2>>29 ^ rs2<<13
+}
+
+func sha512sig1l(rs1, rs2 uint32) uint32 {
+	return rs1<<3 ^ rs1>>6 ^ rs1>>19 ^ rs2>>29 ^ rs2<<26 ^ rs2<<13
+}
+
+func sha512sum0r(rs1, rs2 uint32) uint32 {
+	return rs1<<25 ^ rs1<<30 ^ rs1>>28 ^ rs2>>7 ^ rs2>>2 ^ rs2<<4
+}
+
+func sha512sum1r(rs1, rs2 uint32) uint32 {
+	return rs1<<23 ^ rs1>>14 ^ rs1>>18 ^ rs2>>9 ^ rs2<<18 ^ rs2<<14
+}
+
+//-----------------------------------------------------------------------------
+// SM3
+
+// sm3p0 is the SM3 P0 permutation.
+func sm3p0(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 9) ^ bits.RotateLeft32(x, 17)
+}
+
+// sm3p1 is the SM3 P1 permutation.
+func sm3p1(x uint32) uint32 {
+	return x ^ bits.RotateLeft32(x, 15) ^ bits.RotateLeft32(x, 23)
+}
+
+//-----------------------------------------------------------------------------
+// SM4
+
+// sm4Sbox is the SM4 S-box.
+var sm4Sbox = [256]uint8{
+	0xd6, 0x90, 0xe9, 0xfe, 0xcc, 0xe1, 0x3d, 0xb7, 0x16, 0xb6, 0x14, 0xc2, 0x28, 0xfb, 0x2c, 0x05,
+	0x2b, 0x67, 0x9a, 0x76, 0x2a, 0xbe, 0x04, 0xc3, 0xaa, 0x44, 0x13, 0x26, 0x49, 0x86, 0x06, 0x99,
+	0x9c, 0x42, 0x50, 0xf4, 0x91, 0xef, 0x98, 0x7a, 0x33, 0x54, 0x0b, 0x43, 0xed, 0xcf, 0xac, 0x62,
+	0xe4, 0xb3, 0x1c, 0xa9, 0xc9, 0x08, 0xe8, 0x95, 0x80, 0xdf, 0x94, 0xfa, 0x75, 0x8f, 0x3f, 0xa6,
+	0x47, 0x07, 0xa7, 0xfc, 0xf3, 0x73, 0x17, 0xba, 0x83, 0x59, 0x3c, 0x19, 0xe6, 0x85, 0x4f, 0xa8,
+	0x68, 0x6b, 0x81, 0xb2, 0x71, 0x64, 0xda, 0x8b, 0xf8, 0xeb, 0x0f, 0x4b, 0x70, 0x56, 0x9d, 0x35,
+	0x1e, 0x24, 0x0e, 0x5e, 0x63, 0x58, 0xd1, 0xa2, 0x25, 0x22, 0x7c, 0x3b, 0x01, 0x21, 0x78, 0x87,
+	0xd4, 0x00, 0x46, 0x57, 0x9f, 0xd3, 0x27, 0x52, 0x4c, 0x36, 0x02, 0xe7, 0xa0, 0xc4, 0xc8, 0x9e,
+	0xea, 0xbf, 0x8a, 0xd2, 0x40, 0xc7, 0x38, 0xb5, 0xa3, 0xf7, 0xf2, 0xce, 0xf9, 0x61, 0x15, 0xa1,
+	0xe0, 0xae, 0x5d, 0xa4, 0x9b, 0x34, 0x1a, 0x55, 0xad, 0x93, 0x32, 0x30, 0xf5, 0x8c, 0xb1, 0xe3,
+	0x1d, 0xf6, 0xe2, 0x2e, 0x82, 0x66, 0xca, 0x60, 0xc0, 0x29, 0x23, 0xab, 0x0d, 0x53, 0x4e, 0x6f,
+	0xd5, 0xdb, 0x37, 0x45, 0xde, 0xfd, 0x8e, 0x2f, 0x03, 0xff, 0x6a, 0x72, 0x6d, 0x6c, 0x5b, 0x51,
+	0x8d, 0x1b, 0xaf, 0x92, 0xbb, 0xdd, 0xbc, 0x7f, 0x11, 0xd9, 0x5c, 0x41, 0x1f, 0x10, 0x5a, 0xd8,
+	0x0a, 0xc1, 0x31, 0x88, 0xa5, 0xcd, 0x7b, 0xbd, 0x2d, 0x74, 0xd0, 0x12, 0xb8, 0xe5, 0xb4, 0xb0,
+	0x89, 0x69, 0x97, 0x4a, 0x0c, 0x96, 0x77, 0x7e, 0x65, 0xb9, 0xf1, 0x09, 0xc5, 0x6e, 0xc6, 0x84,
+	0x18, 0xf0, 0x7d, 0xec, 0x3a, 0xdc, 0x4d, 0x20, 0x79, 0xee, 0x5f, 0x3e, 0xd7, 0xcb, 0x39, 0x48,
+}
+
+// sm4Led is the SM4 round linear transform of an S-box output byte.
+// Words are little-endian, so this is the byte-reversed form of the
+// GB/T 32907 transform.
+func sm4Led(x uint32) uint32 {
+	return x ^ x<<8 ^ x<<2 ^ x<<18 ^ (x&0x3f)<<26 ^ (x&0xc0)<<10
+}
+
+// sm4Lks is the SM4 key schedule linear transform of an S-box output byte.
+func sm4Lks(x uint32) uint32 {
+	return x ^ (x&0x07)<<29 ^ (x&0xfe)<<7 ^ (x&0x01)<<23 ^ (x&0xf8)<<13
+}
+
+// sm4 applies the S-box and linear transform to byte bs of rs2 and xors the
+// result (rotated back into the byte position) with rs1.
+func sm4(rs1, rs2 uint32, bs uint, l func(uint32) uint32) uint32 {
+	shamt := int(bs << 3)
+	x := uint32(sm4Sbox[uint8(rs2>>shamt)])
+	return rs1 ^ bits.RotateLeft32(l(x), shamt)
+}
+
+//-----------------------------------------------------------------------------
@@ -0,0 +1,33 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V CSR Naming
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import (
+	"fmt"
+
+	"github.com/deadsy/riscv/csr"
+)
+
+//-----------------------------------------------------------------------------
+
+// CSRName returns the canonical name of a CSR (or a hex string if it is unknown).
+func CSRName(n uint) string {
+	return csr.Name(n)
+}
+
+// csrComment returns the disassembly comment for a CSR access.
+func csrComment(n uint) string {
+	name := CSRName(n)
+	if name == fmt.Sprintf("0x%03x", n) {
+		return fmt.Sprintf("0x%03x unknown csr", n)
+	}
+	return fmt.Sprintf("0x%03x %s", n, name)
+}
+
+//-----------------------------------------------------------------------------
@@ -9,7 +9,9 @@ RISC-V Disassembler
 package rv
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/deadsy/riscv/csr"
 	"github.com/deadsy/riscv/mem"
@@ -32,6 +34,13 @@ var abiFName = [32]string{
 	"fs8", "fs9", "fs10", "fs11", "ft8", "ft9", "ft10", "ft11",
 }
 
+var abiVName = [32]string{
+	"v0", "v1", "v2", "v3", "v4", "v5", "v6", "v7",
+	"v8", "v9", "v10", "v11", "v12", "v13", "v14", "v15",
+	"v16", "v17", "v18", "v19", "v20", "v21", "v22", "v23",
+	"v24", "v25", "v26", "v27", "v28", "v29", "v30", "v31",
+}
+
 // Register numbers.
 const (
 	RegZero = iota // 0: zero
@@ -75,6 +84,10 @@ func daNone(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s TODO", name)
 }
 
+func daReserved(name string, pc uint, ins uint) string {
+	return "(reserved)"
+}
+
 //-----------------------------------------------------------------------------
 // Type I Decodes
 
@@ -83,20 +96,6 @@ func daTypeIa(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], abiXName[rs1], imm)
 }
 
-func daTypeIb(name string, pc uint, ins uint) string {
-	imm, rs1, rd := decodeIa(ins)
-	if rs1 == 0 && imm == 0 {
-		return fmt.Sprintf("nop")
-	}
-	if rs1 == 0 {
-		return fmt.Sprintf("li %s,%d", abiXName[rd], imm)
-	}
-	if imm == 0 {
-		return fmt.Sprintf("mv %s,%s", abiXName[rd], abiXName[rs1])
-	}
-	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], abiXName[rs1], imm)
-}
-
 func daTypeIc(name string, pc uint, ins uint) string {
 	imm, rs1, rd := decodeIa(ins)
 	return fmt.Sprintf("%s %s,%d(%s)", name, abiXName[rd], imm, abiXName[rs1])
@@ -109,29 +108,9 @@ func daTypeId(name string, pc uint, ins uint) string {
 
 func daTypeIe(name string, pc uint, ins uint) string {
 	imm, rs1, rd := decodeIa(ins)
-	if imm == 0 && rd == 0 && rs1 == 1 {
-		return "ret"
-	}
-	if rd == 1 {
-		if imm == 0 {
-			return fmt.Sprintf("%s %s", name, abiXName[rs1])
-		}
-		return fmt.Sprintf("%s %d(%s)", name, imm, abiXName[rs1])
-	}
-	if imm == 0 {
-		return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiXName[rs1])
-	}
 	return fmt.Sprintf("%s %s,%d(%s)", name, abiXName[rd], imm, abiXName[rs1])
 }
 
-func daTypeIf(name string, pc uint, ins uint) string {
-	imm, rs1, rd := decodeIa(ins)
-	if imm == -1 {
-		return fmt.Sprintf("not %s,%s", abiXName[rd], abiXName[rs1])
-	}
-	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], abiXName[rs1], imm)
-}
-
 func daTypeIg(name string, pc uint, ins uint) string {
 	imm, rs1, rd := decodeIa(ins)
 	return fmt.Sprintf("%s %s,%d(%s)", name, abiFName[rd], imm, abiXName[rs1])
@@ -139,32 +118,6 @@ func daTypeIg(name string, pc uint, ins uint) string {
 
 func daTypeIh(name string, pc uint, ins uint) string {
 	csrReg, rs1, rd := decodeIb(ins)
-
-	if csrReg == csr.FCSR {
-		if rd == 0 {
-			return fmt.Sprintf("fscsr %s", abiXName[rs1])
-		}
-		if rs1 == 0 {
-			return fmt.Sprintf("frcsr %s", abiXName[rd])
-		}
-		return fmt.Sprintf("fscsr %s,%s", abiXName[rd], abiXName[rs1])
-	}
-
-	if csrReg == csr.FFLAGS {
-		if rs1 == 0 && name == "csrrs" {
-			return fmt.Sprintf("frflags %s", abiXName[rd])
-		}
-		return fmt.Sprintf("fsflags %s,%s", abiXName[rd], abiXName[rs1])
-	}
-
-	if rd == 0 {
-		return fmt.Sprintf("%s %s,%s", csrRemap1(name), csr.Name(csrReg), abiXName[rs1])
-	}
-
-	if rs1 == 0 && name == "csrrs" {
-		return fmt.Sprintf("%s %s,%s", csrRemap2(name), abiXName[rd], csr.Name(csrReg))
-	}
-
 	return fmt.Sprintf("%s %s,%s,%s", name, abiXName[rd], csr.Name(csrReg), abiXName[rs1])
 }
 
@@ -174,12 +127,6 @@ func daTypeIi(name string, pc uint, ins uint) string {
 
 func daTypeIj(name string, pc uint, ins uint) string {
 	csrReg, uimm, rd := decodeIb(ins)
-	if csrReg == csr.FRM {
-		return fmt.Sprintf("fsrmi %s,%d", abiXName[rd], uimm)
-	}
-	if rd == 0 {
-		return fmt.Sprintf("%s %s,%d", csrRemap1(name), csr.Name(csrReg), uimm)
-	}
 	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], csr.Name(csrReg), uimm)
 }
 
@@ -191,6 +138,38 @@ func daTypeIk(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%s", name, abiXName[rs2], abiXName[rs1])
 }
 
+// aes64ks1i rd,rs1,rnum
+func daTypeIl(name string, pc uint, ins uint) string {
+	rnum, rs1, rd := decodeIe(ins)
+	return fmt.Sprintf("%s %s,%s,%d", name, abiXName[rd], abiXName[rs1], rnum)
+}
+
+// vsetvli rd,rs1,vtypei
+func daTypeIm(name string, pc uint, ins uint) string {
+	vtypei, rs1, rd := decodeIf(ins)
+	return fmt.Sprintf("%s %s,%s,0x%x", name, abiXName[rd], abiXName[rs1], vtypei)
+}
+
+// vsetivli rd,uimm,vtypei
+func daTypeIn(name string, pc uint, ins uint) string {
+	vtypei, uimm, rd := decodeIg(ins)
+	return fmt.Sprintf("%s %s,%d,0x%x", name, abiXName[rd], uimm, vtypei)
+}
+
+// vmName returns the mask operand for the vm field.
+func vmName(vm uint) string {
+	if vm == 0 {
+		return ",v0.t"
+	}
+	return ""
+}
+
+// vle vd,(rs1),vm
+func daTypeIo(name string, pc uint, ins uint) string {
+	vm, rs1, vd := decodeIh(ins)
+	return fmt.Sprintf("%s %s,(%s)%s", name, abiVName[vd], abiXName[rs1], vmName(vm))
+}
+
 //-----------------------------------------------------------------------------
 // Type U Decodes
 
@@ -217,15 +196,19 @@ func daTypeSb(name string, pc uint, ins uint) string {
 
 func daTypeRa(name string, pc uint, ins uint) string {
 	rs2, rs1, _, rd := decodeR(ins)
-	if name == "sub" && rs1 == 0 {
-		return fmt.Sprintf("neg %s,%s", abiXName[rd], abiXName[rs2])
-	}
 	return fmt.Sprintf("%s %s,%s,%s", name, abiXName[rd], abiXName[rs1], abiXName[rs2])
 }
 
+// atomics: lr/sc show the memory ordering suffix
 func daTypeRb(name string, pc uint, ins uint) string {
 	rs2, rs1, _, rd := decodeR(ins)
-	if rs2 == 0 {
+	lr := strings.HasPrefix(name, "lr.")
+	if lr || strings.HasPrefix(name, "sc.") {
+		aq := bitUnsigned(ins, 26, 26, 0)
+		rl := bitUnsigned(ins, 25, 25, 0)
+		name += [4]string{"", ".rl", ".aq", ".aqrl"}[aq<<1|rl]
+	}
+	if lr {
 		return fmt.Sprintf("%s %s,(%s)", name, abiXName[rd], abiXName[rs1])
 	}
 	return fmt.Sprintf("%s %s,%s,(%s)", name, abiXName[rd], abiXName[rs2], abiXName[rs1])
@@ -280,6 +263,43 @@ func daTypeRk(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiFName[rs1])
 }
 
+func daTypeRl(name string, pc uint, ins uint) string {
+	_, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiXName[rs1])
+}
+
+// zext.h is pack (rv32) or packw (rv64) with rs2 == 0
+func daTypeRm(name string, pc uint, ins uint) string {
+	_, rs1, _, rd := decodeR(ins)
+	name = map[uint]string{0x33: "pack", 0x3b: "packw"}[ins&0x7f]
+	return fmt.Sprintf("%s %s,%s,zero", name, abiXName[rd], abiXName[rs1])
+}
+
+// aes32 rd,rs1,rs2,bs
+func daTypeRn(name string, pc uint, ins uint) string {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	return fmt.Sprintf("%s %s,%s,%s,%d", name, abiXName[rd], abiXName[rs1], abiXName[rs2], bs)
+}
+
+// vector-vector vd,vs2,vs1,vm
+func daTypeRo(name string, pc uint, ins uint) string {
+	vm, vs2, vs1, vd := decodeRb(ins)
+	return fmt.Sprintf("%s %s,%s,%s%s", name, abiVName[vd], abiVName[vs2], abiVName[vs1], vmName(vm))
+}
+
+// vector-scalar vd,vs2,rs1,vm
+func daTypeRp(name string, pc uint, ins uint) string {
+	vm, vs2, rs1, vd := decodeRb(ins)
+	return fmt.Sprintf("%s %s,%s,%s%s", name, abiVName[vd], abiVName[vs2], abiXName[rs1], vmName(vm))
+}
+
+// vector-immediate vd,vs2,simm5,vm
+func daTypeRq(name string, pc uint, ins uint) string {
+	vm, vs2, _, vd := decodeRb(ins)
+	imm := bitSigned(ins, 19, 15)
+	return fmt.Sprintf("%s %s,%s,%d%s", name, abiVName[vd], abiVName[vs2], imm, vmName(vm))
+}
+
 //-----------------------------------------------------------------------------
 // Type R4 Decodes
 
@@ -293,16 +313,7 @@ func daTypeR4a(name string, pc uint, ins uint) string {
 
 func daTypeBa(name string, pc uint, ins uint) string {
 	imm, rs2, rs1 := decodeB(ins)
-	adr := int(pc) + imm
-
-	if rs2 == 0 {
-		switch name {
-		case "bge", "beq", "bne", "blt":
-			return fmt.Sprintf("%sz %s,%x", name, abiXName[rs1], adr)
-		}
-	}
-
-	return fmt.Sprintf("%s %s,%s,%x", name, abiXName[rs1], abiXName[rs2], adr)
+	return fmt.Sprintf("%s %s,%s,%x", name, abiXName[rs1], abiXName[rs2], int(pc)+imm)
 }
 
 //-----------------------------------------------------------------------------
@@ -310,9 +321,6 @@ func daTypeBa(name string, pc uint, ins uint) string {
 
 func daTypeJa(name string, pc uint, ins uint) string {
 	imm, rd := decodeJ(ins)
-	if rd == 0 {
-		return fmt.Sprintf("j %x", int(pc)+imm)
-	}
 	return fmt.Sprintf("%s %s,%x", name, abiXName[rd], int(pc)+imm)
 }
 
@@ -320,7 +328,8 @@ func daTypeJa(name string, pc uint, ins uint) string {
 // Type CI Decodes
 
 func daNop(name string, pc uint, ins uint) string {
-	return "nop"
+	imm, rd := decodeCIa(ins)
+	return fmt.Sprintf("addi %s,%s,%d", abiXName[rd], abiXName[rd], imm)
 }
 
 func daTypeCIa(name string, pc uint, ins uint) string {
@@ -330,6 +339,9 @@ func daTypeCIa(name string, pc uint, ins uint) string {
 
 func daTypeCIb(name string, pc uint, ins uint) string {
 	imm := decodeCIb(ins)
+	if imm == 0 {
+		return "(reserved)"
+	}
 	return fmt.Sprintf("%s sp,sp,%d", name, imm)
 }
 
@@ -340,11 +352,17 @@ func daTypeCIc(name string, pc uint, ins uint) string {
 
 func daTypeCId(name string, pc uint, ins uint) string {
 	uimm, rd := decodeCIc(ins)
+	if uimm == 0 {
+		return "(reserved)"
+	}
 	return fmt.Sprintf("%s %s,%s,0x%x", name, abiXName[rd], abiXName[rd], uimm)
 }
 
 func daTypeCIe(name string, pc uint, ins uint) string {
 	uimm, rd := decodeCId(ins)
+	if uimm == 0 {
+		return "(reserved)"
+	}
 	return fmt.Sprintf("%s %s,%s,0x%x", name, abiXName[rd], abiXName[rd], uimm)
 }
 
@@ -355,7 +373,11 @@ func daTypeCIf(name string, pc uint, ins uint) string {
 
 func daTypeCIg(name string, pc uint, ins uint) string {
 	imm, rd := decodeCIf(ins)
-	return fmt.Sprintf("%s %s,0x%x", name, abiXName[rd], imm)
+	if imm == 0 {
+		return "(reserved)"
+	}
+	uimm := uint(imm) & 0xfffff
+	return fmt.Sprintf("%s %s,0x%x", name, abiXName[rd], uimm)
 }
 
 func daTypeCIh(name string, pc uint, ins uint) string {
@@ -363,6 +385,11 @@ func daTypeCIh(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(sp)", name, abiXName[rd], uimm)
 }
 
+func daTypeCIi(name string, pc uint, ins uint) string {
+	uimm, rd := decodeCIg(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rd], uimm)
+}
+
 //-----------------------------------------------------------------------------
 // Type CIW Decodes
 
@@ -372,6 +399,9 @@ func daTypeCIWa(name string, pc uint, ins uint) string {
 
 func daTypeCIWb(name string, pc uint, ins uint) string {
 	uimm, rd := decodeCIW(ins)
+	if uimm == 0 {
+		return "(reserved)"
+	}
 	return fmt.Sprintf("%s %s,sp,%d", name, abiXName[rd], uimm)
 }
 
@@ -391,32 +421,30 @@ func daTypeCJc(name string, pc uint, ins uint) string {
 //-----------------------------------------------------------------------------
 // Type CR Decodes
 
-func daTypeCRa(name string, pc uint, ins uint) string {
-	rd, rs := decodeCR(ins)
-	return fmt.Sprintf("%s %s,%s", name, abiXName[rd], abiXName[rs])
-}
-
-func daTypeCRb(name string, pc uint, ins uint) string {
-	rd, rs := decodeCR(ins)
-	return fmt.Sprintf("%s %s,%s,%s", name, abiXName[rd], abiXName[rd], abiXName[rs])
-}
-
 func daTypeCRc(name string, pc uint, ins uint) string {
 	rd, rs := decodeCRa(ins)
 	return fmt.Sprintf("%s %s,%s,%s", name, abiXName[rd], abiXName[rd], abiXName[rs])
 }
 
+// Quadrant 2, funct3 = 100: jr, mv, ebreak, jalr and add are distinguished by bit 12, rs1 and rs2.
 func daTypeCRd(name string, pc uint, ins uint) string {
-	rs1, _ := decodeCR(ins)
-	if rs1 == 1 {
-		return "ret"
+	rs1, rs2 := decodeCR(ins)
+	if bitUnsigned(ins, 12, 12, 0) == 0 {
+		if rs2 != 0 {
+			return fmt.Sprintf("mv %s,%s", abiXName[rs1], abiXName[rs2])
+		}
+		if rs1 == 0 {
+			return "(reserved)"
+		}
+		return fmt.Sprintf("jr %s", abiXName[rs1])
 	}
-	return fmt.Sprintf("%s %s", name, abiXName[rs1])
-}
-
-func daTypeCRe(name string, pc uint, ins uint) string {
-	rs1, _ := decodeCR(ins)
-	return fmt.Sprintf("%s %s", name, abiXName[rs1])
+	if rs2 != 0 {
+		return fmt.Sprintf("add %s,%s,%s", abiXName[rs1], abiXName[rs1], abiXName[rs2])
+	}
+	if rs1 == 0 {
+		return "ebreak"
+	}
+	return fmt.Sprintf("jalr %s", abiXName[rs1])
 }
 
 //-----------------------------------------------------------------------------
@@ -437,6 +465,11 @@ func daTypeCSc(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(%s)", name, abiFName[rs2], uimm, abiXName[rs1])
 }
 
+func daTypeCSd(name string, pc uint, ins uint) string {
+	uimm, rs1, rs2 := decodeCSa(ins)
+	return fmt.Sprintf("%s %s,%d(%s)", name, abiFName[rs2], uimm, abiXName[rs1])
+}
+
 //-----------------------------------------------------------------------------
 // Type CSS Decodes
 
@@ -455,6 +488,21 @@ func daTypeCSSc(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%d(sp)", name, abiXName[rs2], uimm)
 }
 
+func daTypeCSSd(name string, pc uint, ins uint) string {
+	uimm, rd := decodeCSSa(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rd], uimm)
+}
+
+func daTypeCSSe(name string, pc uint, ins uint) string {
+	imm, rs2 := decodeCSSb(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rs2], imm)
+}
+
+func daTypeCSSf(name string, pc uint, ins uint) string {
+	uimm, rs2 := decodeCSSc(ins)
+	return fmt.Sprintf("%s %s,%d(sp)", name, abiFName[rs2], uimm)
+}
+
 //-----------------------------------------------------------------------------
 // Type CB Decodes
 
@@ -463,6 +511,551 @@ func daTypeCBa(name string, pc uint, ins uint) string {
 	return fmt.Sprintf("%s %s,%x", name, abiXName[rs], int(pc)+imm)
 }
 
+//-----------------------------------------------------------------------------
+// Disassembly Comments
+
+// cmtFunc returns a disassembly comment for an instruction.
+type cmtFunc func(pc uint, ins uint) string
+
+func cmtCSR(pc uint, ins uint) string {
+	csrReg, _, _ := decodeIb(ins)
+	return csrComment(csrReg)
+}
+
+func cmtSEXTB(pc uint, ins uint) string {
+	return "sign-extend byte"
+}
+
+func cmtSEXTH(pc uint, ins uint) string {
+	return "sign-extend halfword"
+}
+
+// vtypeComment returns a description of a vtype value.
+func vtypeComment(vtype uint) string {
+	vt := csr.DecodeVType(vtype)
+	if vt == nil {
+		return "reserved vtype"
+	}
+	return vt.String()
+}
+
+func cmtVSETVLI(pc uint, ins uint) string {
+	vtypei, _, rd := decodeIf(ins)
+	return fmt.Sprintf("vl=%s, %s", abiXName[rd], vtypeComment(vtypei))
+}
+
+func cmtVSETIVLI(pc uint, ins uint) string {
+	vtypei, _, rd := decodeIg(ins)
+	return fmt.Sprintf("vl=%s, %s", abiXName[rd], vtypeComment(vtypei))
+}
+
+// cmtLookup maps instruction names to comment functions.
+var cmtLookup = map[string]cmtFunc{
+	"sext.b":   cmtSEXTB,
+	"sext.h":   cmtSEXTH,
+	"vsetvli":  cmtVSETVLI,
+	"vsetivli": cmtVSETIVLI,
+	"csrrw":    cmtCSR,
+	"csrrs":    cmtCSR,
+	"csrrc":    cmtCSR,
+	"csrrwi":   cmtCSR,
+	"csrrsi":   cmtCSR,
+	"csrrci":   cmtCSR,
+}
+
+//-----------------------------------------------------------------------------
+// Pseudo Instructions
+
+// pseudoFunc returns the pseudo-instruction disassembly for an instruction, or "" if there is none.
+type pseudoFunc func(pc, ins, xlen uint) string
+
+// c.nop is c.addi with rd == 0 and nzimm == 0
+func pseudoCNOP(pc, ins, xlen uint) string {
+	if imm, rd := decodeCIa(ins); rd == 0 && imm == 0 {
+		return "nop"
+	}
+	return ""
+}
+
+// addi rd,rs1,imm
+func pseudoADDI(pc, ins, xlen uint) string {
+	if ins&3 != 3 {
+		return ""
+	}
+	imm, rs1, rd := decodeIa(ins)
+	if rs1 == 0 && imm == 0 && rd == 0 {
+		return "nop"
+	}
+	if rs1 == 0 {
+		return fmt.Sprintf("li %s,%d", abiXName[rd], imm)
+	}
+	if imm == 0 {
+		return fmt.Sprintf("mv %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	return ""
+}
+
+// xori rd,rs1,-1
+func pseudoNOT(pc, ins, xlen uint) string {
+	if imm, rs1, rd := decodeIa(ins); imm == -1 {
+		return fmt.Sprintf("not %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	return ""
+}
+
+// sub rd,zero,rs2
+func pseudoNEG(pc, ins, xlen uint) string {
+	if ins&3 != 3 {
+		return ""
+	}
+	if rs2, rs1, _, rd := decodeR(ins); rs1 == 0 {
+		return fmt.Sprintf("neg %s,%s", abiXName[rd], abiXName[rs2])
+	}
+	return ""
+}
+
+// jalr rd,imm(rs1)
+func pseudoJALR(pc, ins, xlen uint) string {
+	if ins&3 != 3 {
+		return ""
+	}
+	imm, rs1, rd := decodeIa(ins)
+	if imm == 0 && rd == 0 && rs1 == RegRa {
+		return "ret"
+	}
+	if rd == RegRa {
+		if imm == 0 {
+			return fmt.Sprintf("jalr %s", abiXName[rs1])
+		}
+		return fmt.Sprintf("jalr %d(%s)", imm, abiXName[rs1])
+	}
+	if imm == 0 {
+		return fmt.Sprintf("jalr %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	return ""
+}
+
+// c.jr ra
+func pseudoCRET(pc, ins, xlen uint) string {
+	if rs1, _ := decodeCR(ins); rs1 == RegRa {
+		return "ret"
+	}
+	return ""
+}
+
+// jal zero,imm
+func pseudoJ(pc, ins, xlen uint) string {
+	if ins&3 != 3 {
+		return ""
+	}
+	if imm, rd := decodeJ(ins); rd == 0 {
+		return fmt.Sprintf("j %x", int(pc)+imm)
+	}
+	return ""
+}
+
+// beq/bne/blt/bge rs1,zero,imm
+func pseudoBZ(pc, ins, xlen uint) string {
+	if imm, rs2, rs1 := decodeB(ins); rs2 == 0 {
+		name := map[uint]string{0: "beqz", 1: "bnez", 4: "bltz", 5: "bgez"}[bitUnsigned(ins, 14, 12, 0)]
+		return fmt.Sprintf("%s %s,%x", name, abiXName[rs1], int(pc)+imm)
+	}
+	return ""
+}
+
+// csrName maps the funct3 field of a CSR instruction to the instruction name.
+var csrName = [8]string{"", "csrrw", "csrrs", "csrrc", "", "csrrwi", "csrrsi", "csrrci"}
+
+// csrrw/csrrs/csrrc rd,csr,rs1
+func pseudoCSR(pc, ins, xlen uint) string {
+	name := csrName[bitUnsigned(ins, 14, 12, 0)]
+	csrReg, rs1, rd := decodeIb(ins)
+	if name == "csrrs" {
+		if s := pseudoCounter(pc, ins, xlen); s != "" {
+			return s
+		}
+	}
+	if csrReg == csr.FCSR {
+		if rd == 0 {
+			return fmt.Sprintf("fscsr %s", abiXName[rs1])
+		}
+		if rs1 == 0 {
+			return fmt.Sprintf("frcsr %s", abiXName[rd])
+		}
+		return fmt.Sprintf("fscsr %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	if csrReg == csr.FFLAGS {
+		if rs1 == 0 && name == "csrrs" {
+			return fmt.Sprintf("frflags %s", abiXName[rd])
+		}
+		return fmt.Sprintf("fsflags %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	if rd == 0 {
+		return fmt.Sprintf("%s %s,%s", csrRemap1(name), csr.Name(csrReg), abiXName[rs1])
+	}
+	if rs1 == 0 && name == "csrrs" {
+		return fmt.Sprintf("%s %s,%s", csrRemap2(name), abiXName[rd], csr.Name(csrReg))
+	}
+	return ""
+}
+
+// csrrwi/csrrsi/csrrci rd,csr,uimm
+func pseudoCSRI(pc, ins, xlen uint) string {
+	name := csrName[bitUnsigned(ins, 14, 12, 0)]
+	csrReg, uimm, rd := decodeIb(ins)
+	if name == "csrrwi" && (csrReg == csr.FRM || csrReg == csr.FFLAGS) {
+		fname := map[uint]string{csr.FRM: "fsrmi", csr.FFLAGS: "fsflagsi"}[csrReg]
+		if rd == 0 {
+			return fmt.Sprintf("%s %d", fname, uimm)
+		}
+		return fmt.Sprintf("%s %s,%d", fname, abiXName[rd], uimm)
+	}
+	if rd == 0 {
+		return fmt.Sprintf("%s %s,%d", csrRemap1(name), csr.Name(csrReg), uimm)
+	}
+	return ""
+}
+
+// counterName maps the user counter CSRs to their read pseudo-instructions.
+var counterName = map[uint]string{
+	0xc00: "rdcycle",
+	0xc01: "rdtime",
+	0xc02: "rdinstret",
+}
+
+// counterNameRV32 maps the upper half counter CSRs (rv32 only) to their read pseudo-instructions.
+var counterNameRV32 = map[uint]string{
+	0xc80: "rdcycleh",
+	0xc81: "rdtimeh",
+	0xc82: "rdinstreth",
+}
+
+// csrrs rd,counter,zero
+func pseudoCounter(pc, ins, xlen uint) string {
+	csrReg, rs1, rd := decodeIb(ins)
+	if rs1 != 0 {
+		return ""
+	}
+	name, ok := counterName[csrReg]
+	if !ok && xlen == 32 {
+		name, ok = counterNameRV32[csrReg]
+	}
+	if ok {
+		return fmt.Sprintf("%s %s", name, abiXName[rd])
+	}
+	return ""
+}
+
+func pseudoZEXTH(pc, ins, xlen uint) string {
+	_, rs1, _, rd := decodeR(ins)
+	return fmt.Sprintf("zext.h %s,%s", abiXName[rd], abiXName[rs1])
+}
+
+// add.uw rd,rs1,zero
+func pseudoZEXTW(pc, ins, xlen uint) string {
+	if rs2, rs1, _, rd := decodeR(ins); rs2 == 0 {
+		return fmt.Sprintf("zext.w %s,%s", abiXName[rd], abiXName[rs1])
+	}
+	return ""
+}
+
+// pseudoLookup maps instruction names to pseudo-instruction functions.
+var pseudoLookup = map[string]pseudoFunc{
+	"addi":   pseudoADDI,
+	"xori":   pseudoNOT,
+	"sub":    pseudoNEG,
+	"jalr":   pseudoJALR,
+	"jr":     pseudoCRET,
+	"jal":    pseudoJ,
+	"beq":    pseudoBZ,
+	"bne":    pseudoBZ,
+	"blt":    pseudoBZ,
+	"bge":    pseudoBZ,
+	"csrrw":  pseudoCSR,
+	"csrrs":  pseudoCSR,
+	"csrrc":  pseudoCSR,
+	"csrrwi": pseudoCSRI,
+	"csrrsi": pseudoCSRI,
+	"csrrci": pseudoCSRI,
+	"add.uw": pseudoZEXTW,
+	"nop":    pseudoCNOP,
+	"zext.h": pseudoZEXTH,
+}
+
+//-----------------------------------------------------------------------------
+// Branch Target Comments
+
+// targetFunc returns the target address of a branch or jump.
+type targetFunc func(pc uint, ins uint) uint
+
+func targetB(pc uint, ins uint) uint {
+	imm, _, _ := decodeB(ins)
+	return uint(int(pc) + imm)
+}
+
+func targetCB(pc uint, ins uint) uint {
+	imm, _ := decodeCB(ins)
+	return uint(int(pc) + imm)
+}
+
+func targetCJ(pc uint, ins uint) uint {
+	return uint(int(pc) + decodeCJ(ins))
+}
+
+// targetJ handles JAL and the compressed C.JAL (same name).
+func targetJ(pc uint, ins uint) uint {
+	if ins&3 != 3 {
+		return targetCJ(pc, ins)
+	}
+	imm, _ := decodeJ(ins)
+	return uint(int(pc) + imm)
+}
+
+// targetLookup maps instruction names to target address functions.
+var targetLookup = map[string]targetFunc{
+	"jal":  targetJ,
+	"j":    targetCJ,
+	"beq":  targetB,
+	"bne":  targetB,
+	"blt":  targetB,
+	"bge":  targetB,
+	"bltu": targetB,
+	"bgeu": targetB,
+	"beqz": targetCB,
+	"bnez": targetCB,
+}
+
+// daTarget returns the comment (symbol or address) for the target of a branch or jump.
+func (isa *ISA) daTarget(m *mem.Memory, pc uint, ins uint) string {
+	im := isa.lookup(ins)
+	if im == nil {
+		return ""
+	}
+	f, ok := targetLookup[im.name]
+	if !ok {
+		return ""
+	}
+	adr := f(pc, ins)
+	s := m.SymbolContaining(adr)
+	if s == nil {
+		return fmt.Sprintf("0x%x", adr)
+	}
+	if adr == s.Addr {
+		return s.Name
+	}
+	return fmt.Sprintf("%s+0x%x", s.Name, adr-s.Addr)
+}
+
+//-----------------------------------------------------------------------------
+// Compressed HINT Encodings
+
+// hintFunc returns "standard" or "custom" for a HINT encoding, else "".
+type hintFunc func(ins uint) string
+
+// c.nop with nzimm != 0
+func hintNOP(ins uint) string {
+	if imm, _ := decodeCIa(ins); imm != 0 {
+		return "standard"
+	}
+	return ""
+}
+
+// c.addi with rd != 0, nzimm == 0
+func hintADDI(ins uint) string {
+	if imm, rd := decodeCIa(ins); rd != 0 && imm == 0 {
+		return "standard"
+	}
+	return ""
+}
+
+// c.li with rd == 0
+func hintLI(ins uint) string {
+	if _, rd := decodeCIa(ins); rd == 0 {
+		return "standard"
+	}
+	return ""
+}
+
+// c.lui with rd == 0, nzimm != 0
+func hintLUI(ins uint) string {
+	if imm, rd := decodeCIf(ins); rd == 0 && imm != 0 {
+		return "standard"
+	}
+	return ""
+}
+
+// c.slli with rd == 0, nzuimm != 0
+func hintSLLI(ins uint) string {
+	if uimm, rd := decodeCId(ins); rd == 0 && uimm != 0 {
+		return "custom"
+	}
+	return ""
+}
+
+// c.mv and c.add with rd == 0, rs2 != 0
+func hintCR(ins uint) string {
+	if rd, rs2 := decodeCR(ins); rd == 0 && rs2 != 0 {
+		return "standard"
+	}
+	return ""
+}
+
+// hintLookup maps compressed instruction names to HINT functions.
+var hintLookup = map[string]hintFunc{
+	"nop":  hintNOP,
+	"addi": hintADDI,
+	"li":   hintLI,
+	"lui":  hintLUI,
+	"slli": hintSLLI,
+	"mv":   hintCR,
+	"add":  hintCR,
+}
+
+// daHint returns the HINT type of a compressed instruction, or "" if it is not a HINT.
+func (isa *ISA) daHint(ins uint) string {
+	if ins&3 == 3 {
+		return ""
+	}
+	im := isa.lookup(ins)
+	if im == nil {
+		return ""
+	}
+	if f, ok := hintLookup[im.name]; ok {
+		return f(ins)
+	}
+	return ""
+}
+
+//-----------------------------------------------------------------------------
+// Debug Trigger Comments (Sdtrig)
+
+// triggerType maps the tdata1 type field to a trigger name.
+var triggerType = map[uint]string{
+	0:  "none",
+	1:  "legacy",
+	2:  "mcontrol",
+	3:  "icount",
+	4:  "itrigger",
+	5:  "etrigger",
+	6:  "mcontrol6",
+	7:  "tmexttrigger",
+	15: "disabled",
+}
+
+// triggerMatch maps the mcontrol match field to a comparison name.
+var triggerMatch = map[uint]string{
+	0:  "==",
+	1:  "napot",
+	2:  ">=",
+	3:  "<",
+	4:  "mask low",
+	5:  "mask high",
+	8:  "!=",
+	9:  "!napot",
+	12: "!mask low",
+	13: "!mask high",
+}
+
+// triggerAction maps the trigger action field to an action name.
+var triggerAction = map[uint]string{
+	0: "breakpoint",
+	1: "debug",
+}
+
+type triggerBit struct {
+	bit  uint
+	name string
+}
+
+// triggerBits returns the names of the bits set in a trigger value.
+func triggerBits(val uint, bits []triggerBit) string {
+	s := []string{}
+	for _, b := range bits {
+		if val&(1<<b.bit) != 0 {
+			s = append(s, b.name)
+		}
+	}
+	return strings.Join(s, " ")
+}
+
+var mcontrolBits = []triggerBit{{2, "execute"}, {1, "store"}, {0, "load"}, {6, "m"}, {4, "s"}, {3, "u"}}
+var icountBits = []triggerBit{{9, "m"}, {7, "s"}, {6, "u"}}
+
+// tdata1Comment returns a description of a tdata1 trigger configuration.
+func tdata1Comment(val, xlen uint) string {
+	t := bitUnsigned(val, xlen-1, xlen-4, 0)
+	name, ok := triggerType[t]
+	if !ok {
+		name = fmt.Sprintf("type %d", t)
+	}
+	switch t {
+	case 2, 6:
+		match := bitUnsigned(val, 10, 7, 0)
+		action := bitUnsigned(val, 15, 12, 0)
+		return fmt.Sprintf("%s: %s, match %s, action %s", name, triggerBits(val, mcontrolBits), triggerMatch[match], triggerAction[action])
+	case 3:
+		count := bitUnsigned(val, 23, 10, 0)
+		action := bitUnsigned(val, 5, 0, 0)
+		return fmt.Sprintf("%s: count %d %s, action %s", name, count, triggerBits(val, icountBits), triggerAction[action])
+	}
+	return name
+}
+
+//-----------------------------------------------------------------------------
+// CPU State Comments
+
+// stateFunc returns a disassembly comment using the current CPU state.
+type stateFunc func(m *RV, ins uint) string
+
+func stateMRET(m *RV, ins uint) string {
+	return fmt.Sprintf("return to 0x%x, %s mode", m.CSR.GetMEPC(), m.CSR.GetMPP())
+}
+
+func stateSRET(m *RV, ins uint) string {
+	return fmt.Sprintf("return to 0x%x, %s mode", m.CSR.GetSEPC(), m.CSR.GetSPP())
+}
+
+// stateTrigger annotates writes to the debug trigger CSRs.
+func stateTrigger(m *RV, ins uint) string {
+	csrReg, rs1, _ := decodeIb(ins)
+	val := uint(m.rdX(rs1))
+	if bitUnsigned(ins, 14, 14, 0) == 1 {
+		// csrrwi
+		val = rs1
+	}
+	switch csrReg {
+	case csr.TSELECT:
+		return fmt.Sprintf("%s = %d", csrComment(csrReg), val)
+	case csr.TDATA1:
+		return fmt.Sprintf("%s %s", csrComment(csrReg), tdata1Comment(val, m.xlen))
+	case csr.TDATA2:
+		if s := m.Mem.SymbolContaining(val); s != nil && s.Addr == val {
+			return fmt.Sprintf("%s = 0x%x %s", csrComment(csrReg), val, s.Name)
+		}
+		return fmt.Sprintf("%s = 0x%x", csrComment(csrReg), val)
+	}
+	return ""
+}
+
+// stateLookup maps instruction names to CPU state comment functions.
+var stateLookup = map[string]stateFunc{
+	"mret":   stateMRET,
+	"sret":   stateSRET,
+	"csrrw":  stateTrigger,
+	"csrrwi": stateTrigger,
+}
+
+// daState returns the CPU state dependent disassembly comment for an instruction.
+func (m *RV) daState(ins uint) string {
+	im := m.isa.lookup(ins)
+	if im != nil {
+		if f, ok := stateLookup[im.name]; ok {
+			return f(m, ins)
+		}
+	}
+	return ""
+}
+
 //-----------------------------------------------------------------------------
 
 // Disassembly returns the result of the disassembler call.
@@ -470,31 +1063,85 @@ type Disassembly struct {
 	Dump     string // address and memory bytes
 	Symbol   string // symbol for the address (if any)
 	Assembly string // assembly instructions
+	Comment  string // comment for the instruction (if any)
 	Length   uint   // length in bytes of decode
 }
 
+// daJSON is the JSON representation of a disassembly.
+type daJSON struct {
+	Dump        string
+	Symbol      string
+	Instruction string
+	Comment     string
+	N           uint
+}
+
+// MarshalJSON implements json.Marshaler.
+func (da *Disassembly) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&daJSON{da.Dump, da.Symbol, da.Assembly, da.Comment, da.Length})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (da *Disassembly) UnmarshalJSON(b []byte) error {
+	var x daJSON
+	err := json.Unmarshal(b, &x)
+	if err != nil {
+		return err
+	}
+	*da = Disassembly{x.Dump, x.Symbol, x.Instruction, x.Comment, x.N}
+	return nil
+}
+
 func (da *Disassembly) String() string {
+	s := fmt.Sprintf("%s    %-18s", da.Dump, da.Assembly)
+	if da.Comment != "" {
+		s += fmt.Sprintf(" ; %s", da.Comment)
+	}
 	if da.Symbol != "" {
-		return fmt.Sprintf("%s    %-18s %s", da.Dump, da.Assembly, util.GreenString(da.Symbol))
+		s += fmt.Sprintf(" %s", util.GreenString(da.Symbol))
 	}
-	return fmt.Sprintf("%s    %-18s", da.Dump, da.Assembly)
+	return s
 }
 
 //-----------------------------------------------------------------------------
 
-// daInstruction returns the disassembly for a 16/32-bit instruction.
-func (isa *ISA) daInstruction(pc uint, ins uint) string {
+// daInstruction returns the disassembly for a 16/32-bit instruction on an xlen-bit CPU.
+func (isa *ISA) daInstruction(pc, ins, xlen uint) string {
+	im := isa.lookup(ins)
+	if im == nil {
+		return "illegal"
+	}
+	if isa.RecognizePseudos {
+		if f, ok := pseudoLookup[im.name]; ok {
+			if s := f(pc, ins, xlen); s != "" {
+				return s
+			}
+		}
+	}
+	return im.defn.da(im.name, pc, ins)
+}
+
+// daComment returns the disassembly comment for a 16/32-bit instruction.
+func (isa *ISA) daComment(pc uint, ins uint) string {
 	im := isa.lookup(ins)
 	if im != nil {
-		return im.defn.da(im.name, pc, ins)
+		if f, ok := cmtLookup[im.name]; ok {
+			return f(pc, ins)
+		}
 	}
-	return "illegal"
+	return ""
 }
 
 //-----------------------------------------------------------------------------
 
-// Disassemble a RISC-V instruction at the address.
-func (isa *ISA) Disassemble(m *mem.Memory, adr uint) *Disassembly {
+// Disassemble a RISC-V instruction at the address for an xlen-bit CPU.
+func (isa *ISA) Disassemble(m *mem.Memory, adr, xlen uint) *Disassembly {
+	da, _ := isa.disassemble(m, adr, xlen)
+	return da
+}
+
+// disassemble returns the disassembly and the instruction at the address.
+func (isa *ISA) disassemble(m *mem.Memory, adr, xlen uint) (*Disassembly, uint) {
 	var da Disassembly
 	// symbol
 	s := m.SymbolByAddress(adr)
@@ -506,14 +1153,26 @@ func (isa *ISA) Disassemble(m *mem.Memory, adr uint) *Disassembly {
 	pcStr := m.AddrStr(adr)
 	if ins&3 == 3 {
 		da.Dump = fmt.Sprintf("%s: %08x", pcStr, uint32(ins))
-		da.Assembly = isa.daInstruction(adr, ins)
+		da.Assembly = isa.daInstruction(adr, ins, xlen)
 		da.Length = 4
 	} else {
 		da.Dump = fmt.Sprintf("%s: %04x    ", pcStr, uint16(ins))
-		da.Assembly = isa.daInstruction(adr, ins)
+		da.Assembly = isa.daInstruction(adr, ins, xlen)
 		da.Length = 2
 	}
-	return &da
+	da.Comment = isa.daComment(adr, ins)
+	if da.Comment == "" {
+		da.Comment = isa.daTarget(m, adr, ins)
+	}
+	if isa.RecognizeHints {
+		if hint := isa.daHint(ins); hint != "" {
+			da.Assembly = "c.hint." + da.Assembly
+			if da.Comment == "" {
+				da.Comment = hint + " hint"
+			}
+		}
+	}
+	return &da, ins
 }
 
 //-----------------------------------------------------------------------------
@@ -91,6 +91,10 @@ var rv32iTest = []daTest{
 	{0, 0x3407f2f3, "csrrci t0,mscratch,15"},
 	{0, 0x34202f73, "csrr t5,mcause"},
 	{0, 0xf1402573, "csrr a0,mhartid"},
+	{0, 0xc0002573, "rdcycle a0"},
+	{0, 0xc01025f3, "rdtime a1"},
+	{0, 0xc0202573, "rdinstret a0"},
+	{0, 0xc0052573, "csrrs a0,cycle,a0"},
 	{0, 0x3400b0f3, "csrrc ra,mscratch,ra"},
 	{0, 0x34013173, "csrrc sp,mscratch,sp"},
 	{0, 0x3401b1f3, "csrrc gp,mscratch,gp"},
@@ -99,6 +103,7 @@ var rv32iTest = []daTest{
 	{0, 0x34003cf3, "csrrc s9,mscratch,zero"},
 	{0, 0x30200073, "mret"},
 	{0, 0x0ff0000f, "fence"},
+	{0, 0x8330000f, "fence.tso"},
 	{0, 0x010fa033, "slt zero,t6,a6"},
 	{0, 0x00ff20b3, "slt ra,t5,a5"},
 	{0, 0x00cda233, "slt tp,s11,a2"},
@@ -122,6 +127,15 @@ var rv32iTest = []daTest{
 	{0, 0x001015f3, "fsflags a1,zero"},
 	{0, 0x00215573, "fsrmi a0,2"},
 	{0, 0x00127573, "csrrci a0,fflags,4"},
+	{0, 0x3001d573, "csrrwi a0,mstatus,3"},
+	{0, 0x300465f3, "csrrsi a1,mstatus,8"},
+	{0, 0x300ff673, "csrrci a2,mstatus,31"},
+	{0, 0x30046073, "csrsi mstatus,8"},
+	{0, 0x0021e573, "csrrsi a0,frm,3"},
+	{0, 0x0020f573, "csrrci a0,frm,1"},
+	{0, 0x00225073, "fsrmi 4"},
+	{0, 0x00125573, "fsflagsi a0,4"},
+	{0, 0x0010d073, "fsflagsi 1"},
 	{0, 0xeee59583, "lh a1,-274(a1)"},
 	{0, 0x00a29a23, "sh a0,20(t0)"},
 	{0, 0x00000013, "nop"},
@@ -129,6 +143,19 @@ var rv32iTest = []daTest{
 	{0, 0x12000073, "sfence.vma"},
 }
 
+// the upper half counter reads are rv32 only
+var rv32CounterTest = []daTest{
+	{0, 0xc8002573, "rdcycleh a0"},
+	{0, 0xc81025f3, "rdtimeh a1"},
+	{0, 0xc8202573, "rdinstreth a0"},
+}
+
+var rv64CounterTest = []daTest{
+	{0, 0xc8002573, "csrr a0,cycleh"},
+	{0, 0xc81025f3, "csrr a1,timeh"},
+	{0, 0xc8202573, "csrr a0,instreth"},
+}
+
 var rv32mTest = []daTest{
 	{0, 0x025535b3, "mulhu a1,a0,t0"},
 	{0, 0x036484b3, "mul s1,s1,s6"},
@@ -145,6 +172,11 @@ var rv32aTest = []daTest{
 	{0, 0x60b6a72f, "amoand.w a4,a1,(a3)"},
 	{0, 0x00b6a72f, "amoadd.w a4,a1,(a3)"},
 	{0, 0xe0b6a72f, "amomaxu.w a4,a1,(a3)"},
+	{0, 0x1405a52f, "lr.w.aq a0,(a1)"},
+	{0, 0x1605a52f, "lr.w.aqrl a0,(a1)"},
+	{0, 0x1ac5a52f, "sc.w.rl a0,a2,(a1)"},
+	{0, 0x1ec5a52f, "sc.w.aqrl a0,a2,(a1)"},
+	{0, 0x0805a52f, "amoswap.w a0,zero,(a1)"},
 }
 
 var rv32fTest = []daTest{
@@ -210,6 +242,9 @@ var rv32cTest = []daTest{
 	{0, 0x44d2, "lw s1,20(sp)"},
 	{0, 0x6145, "addi sp,sp,48"},
 	{0, 0x1800, "addi s0,sp,48"},
+	{0, 0x0004, "(reserved)"},
+	{0, 0x001c, "(reserved)"},
+	{0, 0x0020, "addi s0,sp,8"},
 	{0, 0x1101, "addi sp,sp,-32"},
 	{0, 0x873e, "mv a4,a5"},
 	{0, 0x8391, "srli a5,a5,0x4"},
@@ -218,6 +253,11 @@ var rv32cTest = []daTest{
 	{0, 0x97b6, "add a5,a5,a3"},
 	{0x186, 0xa029, "j 190"},
 	{0, 0x67ad, "lui a5,0xb"},
+	{0, 0x77fd, "lui a5,0xfffff"},
+	{0, 0x6781, "(reserved)"},
+	{0, 0x6001, "(reserved)"},
+	{0, 0x6005, "lui zero,0x1"},
+	{0, 0x6101, "(reserved)"},
 	{0x1d0, 0xf3e1, "bnez a5,190"},
 	{0, 0x0001, "nop"},
 	{0, 0x8e09, "sub a2,a2,a0"},
@@ -231,6 +271,12 @@ var rv32cTest = []daTest{
 	{0, 0x9782, "jalr a5"},
 	{0x8000003e, 0xbfe5, "j 80000036"},
 	{0x80000044, 0xe101, "bnez a0,80000044"},
+	{0x100, 0xcc7d, "beqz s0,1fe"},
+	{0x1000, 0xd001, "beqz s0,f00"},
+	{0x100, 0xeffd, "bnez a5,1fe"},
+	{0, 0xaffd, "j 7fe"},
+	{0x1000, 0xb001, "j 800"},
+	{0x100, 0xa009, "j 102"},
 	{0, 0x8f02, "jr t5"},
 	{0, 0x52fd, "li t0,-1"},
 	{0, 0x8cc9, "or s1,s1,a0"},
@@ -242,20 +288,42 @@ var rv32cTest = []daTest{
 	{0, 0xc1c8, "sw a0,4(a1)"},
 	{0, 0x8431, "srai s0,s0,0xc"},
 	{0, 0x8031, "srli s0,s0,0xc"},
+	{0, 0x8381, "(reserved)"},
+	{0, 0x0702, "(reserved)"},
+	{0, 0x0012, "slli zero,zero,0x4"},
+	{0, 0x0005, "addi zero,zero,1"},
+	{0, 0x4005, "li zero,1"},
+	{0, 0x8002, "(reserved)"},
+	{0, 0x8502, "jr a0"},
+	{0, 0x8006, "mv zero,ra"},
+	{0, 0x9002, "ebreak"},
+	{0, 0x9082, "jalr ra"},
+	{0, 0x9006, "add zero,zero,ra"},
 }
 
 var rv32cOnlyTest = []daTest{
 	{0x358, 0x3d7d, "jal ra,216"},
+	{0, 0x2ffd, "jal ra,7fe"},
+	{0x1000, 0x3001, "jal ra,800"},
+	{0, 0x9401, "(reserved)"},
+	{0, 0x9385, "(reserved)"},
+	{0, 0x1702, "(reserved)"},
 }
 
 var rv32fcTest = []daTest{
 	{0, 0x7654, "flw fa3,44(a2)"},
 	{0, 0xfedc, "fsw fa5,60(a3)"},
+	{0, 0x65a2, "flw fa1,8(sp)"},
+	{0, 0xe42a, "fsw fa0,8(sp)"},
 }
 
 var rv32dcTest = []daTest{
 	{0, 0x3210, "fld fa2,32(a2)"},
 	{0, 0xba98, "fsd fa4,48(a3)"},
+	{0, 0x2250, "fld fa2,128(a2)"},
+	{0, 0xa6d8, "fsd fa4,136(a3)"},
+	{0, 0x25a2, "fld fa1,8(sp)"},
+	{0, 0xa42a, "fsd fa0,8(sp)"},
 }
 
 //-----------------------------------------------------------------------------
@@ -310,6 +378,9 @@ var rv64aTest = []daTest{
 	{0, 0xa0b6b72f, "amomax.d a4,a1,(a3)"},
 	{0, 0xc0b6b72f, "amominu.d a4,a1,(a3)"},
 	{0, 0xe0b6b72f, "amomaxu.d a4,a1,(a3)"},
+	{0, 0x1405b52f, "lr.d.aq a0,(a1)"},
+	{0, 0x1ac5b52f, "sc.d.rl a0,a2,(a1)"},
+	{0, 0x06c5b52f, "amoadd.d a0,a2,(a1)"},
 }
 
 var rv64fTest = []daTest{}
@@ -338,18 +409,158 @@ var rv64cTest = []daTest{
 	{0, 0xe04a, "sd s2,0(sp)"},
 	{0, 0xec06, "sd ra,24(sp)"},
 	{0, 0xe426, "sd s1,8(sp)"},
+	{0, 0x9401, "srai s0,s0,0x20"},
+	{0, 0x9385, "srli a5,a5,0x21"},
+	{0, 0x1702, "slli a4,a4,0x20"},
+	{0, 0x7654, "ld a3,168(a2)"},
+	{0, 0xfedc, "sd a5,184(a3)"},
+	{0, 0x65a2, "ld a1,8(sp)"},
+	{0, 0xe42a, "sd a0,8(sp)"},
+}
+
+var rv32vTest = []daTest{
+	{0, 0x0d25f557, "vsetvli a0,a1,0xd2"},
+	{0, 0x0d207557, "vsetvli a0,zero,0xd2"},
+	{0, 0xc082f557, "vsetivli a0,5,0x8"},
+	{0, 0x80c5f557, "vsetvl a0,a1,a2"},
+	{0, 0x02050087, "vle8.v v1,(a0)"},
+	{0, 0x0005d107, "vle16.v v2,(a1),v0.t"},
+	{0, 0x02066407, "vle32.v v8,(a2)"},
+	{0, 0x00017f87, "vle64.v v31,(sp),v0.t"},
+	{0, 0x022180d7, "vadd.vv v1,v2,v3"},
+	{0, 0x00428357, "vadd.vv v6,v4,v5,v0.t"},
+	{0, 0x022540d7, "vadd.vx v1,v2,a0"},
+	{0, 0x0085c457, "vadd.vx v8,v8,a1,v0.t"},
+	{0, 0x022db0d7, "vadd.vi v1,v2,-5"},
+	{0, 0x0027b1d7, "vadd.vi v3,v2,15,v0.t"},
+}
+
+var rv32zbbTest = []daTest{
+	{0, 0x60059513, "clz a0,a1"},
+	{0, 0x60159513, "ctz a0,a1"},
+	{0, 0x60259513, "cpop a0,a1"},
+	{0, 0x60459513, "sext.b a0,a1"},
+	{0, 0x60529493, "sext.h s1,t0"},
+	{0, 0x40c5f533, "andn a0,a1,a2"},
+	{0, 0x40c5e533, "orn a0,a1,a2"},
+	{0, 0x40c5c533, "xnor a0,a1,a2"},
+	{0, 0x0ac5c533, "min a0,a1,a2"},
+	{0, 0x0ac5d533, "minu a0,a1,a2"},
+	{0, 0x0ac5e533, "max a0,a1,a2"},
+	{0, 0x0ac5f533, "maxu a0,a1,a2"},
+	{0, 0x60c59533, "rol a0,a1,a2"},
+	{0, 0x60c5d533, "ror a0,a1,a2"},
+	{0, 0x6035d513, "rori a0,a1,0x3"},
+	{0, 0x63f5d513, "rori a0,a1,0x3f"},
+	{0, 0x00c59533, "sll a0,a1,a2"},
+	{0, 0x00c5d533, "srl a0,a1,a2"},
+	{0, 0x40c5d533, "sra a0,a1,a2"},
+}
+
+var rv32zbbOnlyTest = []daTest{
+	{0, 0x0805c533, "zext.h a0,a1"},
+}
+
+var rv64zbbTest = []daTest{
+	{0, 0x0805c53b, "zext.h a0,a1"},
+	{0, 0x6005951b, "clzw a0,a1"},
+	{0, 0x6015951b, "ctzw a0,a1"},
+	{0, 0x6025951b, "cpopw a0,a1"},
+	{0, 0x60c5953b, "rolw a0,a1,a2"},
+	{0, 0x60c5d53b, "rorw a0,a1,a2"},
+	{0, 0x6075d51b, "roriw a0,a1,0x7"},
+}
+
+var rv32zbaTest = []daTest{
+	{0, 0x20c5a533, "sh1add a0,a1,a2"},
+	{0, 0x20c5c533, "sh2add a0,a1,a2"},
+	{0, 0x20c5e533, "sh3add a0,a1,a2"},
+}
+
+var rv32zbsTest = []daTest{
+	{0, 0x48c59533, "bclr a0,a1,a2"},
+	{0, 0x48359513, "bclri a0,a1,0x3"},
+	{0, 0x48c5d533, "bext a0,a1,a2"},
+	{0, 0x4835d513, "bexti a0,a1,0x3"},
+	{0, 0x68c59533, "binv a0,a1,a2"},
+	{0, 0x68359513, "binvi a0,a1,0x3"},
+	{0, 0x28c59533, "bset a0,a1,a2"},
+	{0, 0x28359513, "bseti a0,a1,0x3"},
+	{0, 0x2bf59513, "bseti a0,a1,0x3f"},
+}
+
+var rv32zbcTest = []daTest{
+	{0, 0x0ac5b533, "clmulh a0,a1,a2"},
+	{0, 0x0af736b3, "clmulh a3,a4,a5"},
+}
+
+var rv32zbkbTest = []daTest{
+	{0, 0x6875d513, "brev8 a0,a1"},
+	{0, 0x08c5c533, "pack a0,a1,a2"},
+	{0, 0x0805c533, "zext.h a0,a1"},
+	{0, 0x08c5f533, "packh a0,a1,a2"},
+}
+
+var rv32zbkxTest = []daTest{
+	{0, 0x28c5a533, "xperm4 a0,a1,a2"},
+	{0, 0x28c5c533, "xperm8 a0,a1,a2"},
+}
+
+var rv32zknTest = []daTest{
+	{0, 0x2ab50533, "aes32dsi a0,a0,a1,0"},
+	{0, 0xeec50533, "aes32dsmi a0,a0,a2,3"},
+	{0, 0x62c50533, "aes32esi a0,a0,a2,1"},
+	{0, 0xa6d50533, "aes32esmi a0,a0,a3,2"},
+}
+
+var rv32zkshTest = []daTest{
+	{0, 0x10859513, "sm3p0 a0,a1"},
+	{0, 0x10959613, "sm3p1 a2,a1"},
+}
+
+var rv32zksedTest = []daTest{
+	{0, 0x30b50533, "sm4ed a0,a0,a1,0"},
+	{0, 0xf0b50533, "sm4ed a0,a0,a1,3"},
+	{0, 0x74b50533, "sm4ks a0,a0,a1,1"},
+	{0, 0xb4b50533, "sm4ks a0,a0,a1,2"},
+}
+
+var rv32zknhTest = []daTest{
+	{0, 0x5cb50633, "sha512sig0h a2,a0,a1"},
+	{0, 0x54a586b3, "sha512sig0l a3,a1,a0"},
+	{0, 0x5eb50633, "sha512sig1h a2,a0,a1"},
+	{0, 0x56a586b3, "sha512sig1l a3,a1,a0"},
+	{0, 0x50b50633, "sha512sum0r a2,a0,a1"},
+	{0, 0x52a586b3, "sha512sum1r a3,a1,a0"},
+}
+
+var rv64zknTest = []daTest{
+	{0, 0x30059513, "aes64im a0,a1"},
+	{0, 0x31059613, "aes64ks1i a2,a1,0"},
+	{0, 0x31a59613, "aes64ks1i a2,a1,10"},
+	{0, 0x7ea60533, "aes64ks2 a0,a2,a0"},
+}
+
+var rv64zbaTest = []daTest{
+	{0, 0x08c5853b, "add.uw a0,a1,a2"},
+	{0, 0x0805853b, "zext.w a0,a1"},
+	{0, 0x0835951b, "slli.uw a0,a1,0x3"},
+	{0, 0x0a15969b, "slli.uw a3,a1,0x21"},
+	{0, 0x20c5a53b, "sh1add.uw a0,a1,a2"},
+	{0, 0x20c5c53b, "sh2add.uw a0,a1,a2"},
+	{0, 0x20c5e53b, "sh3add.uw a0,a1,a2"},
 }
 
 //-----------------------------------------------------------------------------
 
-func testSet(module []ISAModule, tests []daTest) error {
+func testSet(xlen uint, module []ISAModule, tests []daTest) error {
 	isa := NewISA(0)
 	err := isa.Add(module)
 	if err != nil {
 		return err
 	}
 	for _, v := range tests {
-		da := isa.daInstruction(v.pc, v.ins)
+		da := isa.daInstruction(v.pc, v.ins, xlen)
 		if v.da != da {
 			return fmt.Errorf("ins %08x \"%s\" (expected) \"%s\" (actual)", v.ins, v.da, da)
 		}
@@ -408,12 +619,47 @@ func Test_Disassembly(t *testing.T) {
 		{[]ISAModule{ISArv64f}, rv64fTest},
 		{[]ISAModule{ISArv64d}, rv64dTest},
 		{[]ISAModule{ISArv64c}, rv64cTest},
+		// bit manipulation
+		{[]ISAModule{ISArv32i, ISArv32zbb}, rv32zbbTest},
+		{[]ISAModule{ISArv32zbbOnly}, rv32zbbOnlyTest},
+		{[]ISAModule{ISArv64zbb}, rv64zbbTest},
+		{[]ISAModule{ISArv32zba}, rv32zbaTest},
+		{[]ISAModule{ISArv64zba}, rv64zbaTest},
+		{[]ISAModule{ISArv32zbs}, rv32zbsTest},
+		{[]ISAModule{ISArv32zbc}, rv32zbcTest},
+		{[]ISAModule{ISArv32zbbOnly, ISArv32zbkb}, rv32zbkbTest},
+		{[]ISAModule{ISArv32zbkx}, rv32zbkxTest},
+		{[]ISAModule{ISArv32zknd, ISArv32zkne}, rv32zknTest},
+		{[]ISAModule{ISArv64zknd, ISArv64zkne}, rv64zknTest},
+		{[]ISAModule{ISArv32zknh}, rv32zknhTest},
+		{[]ISAModule{ISArv32zksh}, rv32zkshTest},
+		{[]ISAModule{ISArv32zksed}, rv32zksedTest},
+		{[]ISAModule{ISArv32v}, rv32vTest},
 		// together
 		{ISArv32gc, rv32Tests},
 		{ISArv64gc, rv64Tests},
 	}
 	for _, v := range testCases {
-		err := testSet(v.module, v.tests)
+		for _, xlen := range []uint{32, 64} {
+			err := testSet(xlen, v.module, v.tests)
+			if err != nil {
+				fmt.Printf("%s\n", err)
+				t.Error("FAIL")
+			}
+		}
+	}
+
+	// xlen dependent disassembly
+	xlenCases := []struct {
+		xlen   uint
+		module []ISAModule
+		tests  []daTest
+	}{
+		{32, ISArv32gc, rv32CounterTest},
+		{64, ISArv64gc, rv64CounterTest},
+	}
+	for _, v := range xlenCases {
+		err := testSet(v.xlen, v.module, v.tests)
 		if err != nil {
 			fmt.Printf("%s\n", err)
 			t.Error("FAIL")
@@ -422,3 +668,89 @@ func Test_Disassembly(t *testing.T) {
 }
 
 //-----------------------------------------------------------------------------
+
+// FuzzDisassemble checks that disassembling any instruction word does not panic.
+func FuzzDisassemble(f *testing.F) {
+	f.Add(uint32(0))
+	f.Add(uint32(0xffffffff))
+	tests := [][]daTest{
+		rv32iTest, rv32mTest, rv32aTest, rv32fTest, rv32dTest,
+		rv32cTest, rv32cOnlyTest, rv32fcTest, rv32dcTest,
+		rv64iTest, rv64mTest, rv64aTest, rv64fTest, rv64dTest, rv64cTest,
+		rv32zbbTest, rv32zbbOnlyTest, rv64zbbTest, rv32zbaTest, rv64zbaTest,
+		rv32zbsTest, rv32zbcTest, rv32zbkbTest, rv32zbkxTest,
+		rv32zknTest, rv64zknTest, rv32zknhTest,
+		rv32zkshTest, rv32zksedTest, rv32vTest,
+	}
+	for _, x := range tests {
+		for _, v := range x {
+			f.Add(uint32(v.ins))
+		}
+	}
+	cpus := []*RV{
+		newTestRV(f, 32, ISArv32gc, nil),
+		newTestRV(f, 64, ISArv64gc, nil),
+		newTestRV(f, 32, append(append([]ISAModule{}, ISArv32gc...), ISArv32bkv...), nil),
+		newTestRV(f, 64, append(append([]ISAModule{}, ISArv64gc...), ISArv64bkv...), nil),
+	}
+	f.Fuzz(func(t *testing.T, ins uint32) {
+		for _, m := range cpus {
+			m.Mem.Wr32(0, ins)
+			for _, adr := range []uint{0, 2} {
+				da := m.Disassemble(adr)
+				if da == nil || da.Dump == "" {
+					t.Fatalf("rv%d ins %08x @ %d: bad disassembly", m.xlen, ins, adr)
+				}
+			}
+		}
+	})
+}
+
+//-----------------------------------------------------------------------------
+
+var commentTest = []daTest{
+	{0, 0x00000013, ""},
+	{0, 0x30529073, "0x305 mtvec"},
+	{0, 0x340290f3, "0x340 mscratch"},
+	{0, 0x18005073, "0x180 satp"},
+	{0, 0x3400f0f3, "0x340 mscratch"},
+	{0, 0xf1402573, "0xf14 mhartid"},
+	{0, 0x00302573, "0x003 fcsr"},
+	{0, 0x7c002573, "0x7c0 unknown csr"},
+}
+
+var vtypeCommentTest = []daTest{
+	{0, 0x0d25f557, "vl=a0, SEW=32, LMUL=4, ta, ma"},
+	{0, 0x0c55f557, "vl=a0, SEW=8, LMUL=1/8, ta, ma"},
+	{0, 0x0c907057, "vl=zero, SEW=16, LMUL=2, ta, ma"},
+	{0, 0xc082f557, "vl=a0, SEW=16, LMUL=1, tu, mu"},
+	{0, 0xc18ff557, "vl=a0, SEW=64, LMUL=1, tu, mu"},
+	{0, 0x0205f557, "vl=a0, reserved vtype"},
+	{0, 0x1005f557, "vl=a0, reserved vtype"},
+}
+
+func Test_Comment(t *testing.T) {
+	isa := NewISA(0)
+	err := isa.Add(ISArv64gc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range commentTest {
+		cmt := isa.daComment(v.pc, v.ins)
+		if v.da != cmt {
+			t.Errorf("ins %08x \"%s\" (expected) \"%s\" (actual)", v.ins, v.da, cmt)
+		}
+	}
+	err = isa.Add([]ISAModule{ISArv32v})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range vtypeCommentTest {
+		cmt := isa.daComment(v.pc, v.ins)
+		if v.da != cmt {
+			t.Errorf("ins %08x \"%s\" (expected) \"%s\" (actual)", v.ins, v.da, cmt)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
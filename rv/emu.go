@@ -23,7 +23,9 @@ all ones for NaN boxing.
 package rv
 
 import (
+	"fmt"
 	"math"
+	"math/bits"
 	"sync"
 
 	"github.com/deadsy/riscv/csr"
@@ -431,6 +433,12 @@ func emu_FENCE(m *RV, ins uint) error {
 	return nil
 }
 
+func emu_FENCE_TSO(m *RV, ins uint) error {
+	// no-op for a sw emulator
+	m.PC += 4
+	return nil
+}
+
 func emu_FENCE_I(m *RV, ins uint) error {
 	// no-op for a sw emulator
 	m.PC += 4
@@ -523,9 +531,11 @@ func emu_CSRRSI(m *RV, ins uint) error {
 	if err != nil {
 		return m.errCSR(err, ins)
 	}
-	err = m.CSR.Wr(csr, t|uint64(zimm))
-	if err != nil {
-		return m.errCSR(err, ins)
+	if zimm != 0 {
+		err = m.CSR.Wr(csr, t|uint64(zimm))
+		if err != nil {
+			return m.errCSR(err, ins)
+		}
 	}
 	m.wrX(rd, t)
 	m.PC += 4
@@ -538,9 +548,11 @@ func emu_CSRRCI(m *RV, ins uint) error {
 	if err != nil {
 		return m.errCSR(err, ins)
 	}
-	err = m.CSR.Wr(csr, t & ^uint64(zimm))
-	if err != nil {
-		return m.errCSR(err, ins)
+	if zimm != 0 {
+		err = m.CSR.Wr(csr, t & ^uint64(zimm))
+		if err != nil {
+			return m.errCSR(err, ins)
+		}
 	}
 	m.wrX(rd, t)
 	m.PC += 4
@@ -1564,6 +1576,9 @@ func emu_C_ILLEGAL(m *RV, ins uint) error {
 
 func emu_C_ADDI4SPN(m *RV, ins uint) error {
 	uimm, rd := decodeCIW(ins)
+	if uimm == 0 {
+		return m.errIllegal(ins)
+	}
 	m.wrX(rd, m.rdX(RegSp)+uint64(uimm))
 	m.PC += 2
 	return nil
@@ -1615,6 +1630,9 @@ func emu_C_LI(m *RV, ins uint) error {
 
 func emu_C_ADDI16SP(m *RV, ins uint) error {
 	imm := decodeCIb(ins)
+	if imm == 0 {
+		return m.errIllegal(ins)
+	}
 	m.wrX(RegSp, uint64(int(m.rdX(RegSp))+imm))
 	m.PC += 2
 	return nil
@@ -1728,10 +1746,6 @@ func emu_C_SLLI(m *RV, ins uint) error {
 	return nil
 }
 
-func emu_C_SLLI64(m *RV, ins uint) error {
-	return m.errTodo()
-}
-
 func emu_C_LWSP(m *RV, ins uint) error {
 	uimm, rd := decodeCSSa(ins)
 	if rd == 0 {
@@ -1758,9 +1772,11 @@ func emu_C_JR(m *RV, ins uint) error {
 
 func emu_C_MV(m *RV, ins uint) error {
 	rd, rs := decodeCR(ins)
-	if rs != 0 {
-		m.wrX(rd, m.rdX(rs))
+	if rs == 0 {
+		// c.jr encoding
+		return m.errIllegal(ins)
 	}
+	m.wrX(rd, m.rdX(rs))
 	m.PC += 2
 	return nil
 }
@@ -1783,6 +1799,10 @@ func emu_C_JALR(m *RV, ins uint) error {
 
 func emu_C_ADD(m *RV, ins uint) error {
 	rd, rs := decodeCR(ins)
+	if rs == 0 {
+		// c.jalr/c.ebreak encoding
+		return m.errIllegal(ins)
+	}
 	m.wrX(rd, m.rdX(rd)+m.rdX(rs))
 	m.PC += 2
 	return nil
@@ -1813,38 +1833,122 @@ func emu_C_JAL(m *RV, ins uint) error {
 // rv32fc
 
 func emu_C_FLW(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs1, rd := decodeCS(ins)
+	adr := uint(m.rdX(rs1)) + uimm
+	val, err := m.Mem.Rd32(adr)
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.wrFS(rd, val)
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FLWSP(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rd := decodeCSSa(ins)
+	adr := uint(m.rdX(RegSp)) + uimm
+	val, err := m.Mem.Rd32(adr)
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.wrFS(rd, val)
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FSW(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs1, rs2 := decodeCS(ins)
+	adr := uint(m.rdX(rs1)) + uimm
+	err := m.Mem.Wr32(adr, m.rdFS(rs2))
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FSWSP(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs2 := decodeCSSb(ins)
+	adr := uint(m.rdX(RegSp)) + uimm
+	err := m.Mem.Wr32(adr, m.rdFS(rs2))
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.PC += 2
+	return nil
 }
 
 //-----------------------------------------------------------------------------
 // rv32dc
 
 func emu_C_FLD(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs1, rd := decodeCSa(ins)
+	adr := uint(m.rdX(rs1)) + uimm
+	val, err := m.Mem.Rd64(adr)
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.wrFD(rd, val)
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FLDSP(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rd := decodeCIg(ins)
+	adr := uint(m.rdX(RegSp)) + uimm
+	val, err := m.Mem.Rd64(adr)
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.wrFD(rd, val)
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FSD(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs1, rs2 := decodeCSa(ins)
+	adr := uint(m.rdX(rs1)) + uimm
+	err := m.Mem.Wr64(adr, m.rdFD(rs2))
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.PC += 2
+	return nil
 }
 
 func emu_C_FSDSP(m *RV, ins uint) error {
-	return m.errTodo()
+	if m.CSR.IsFloatOff() {
+		return m.errIllegal(ins)
+	}
+	uimm, rs2 := decodeCSSc(ins)
+	adr := uint(m.rdX(RegSp)) + uimm
+	err := m.Mem.Wr64(adr, m.rdFD(rs2))
+	if err != nil {
+		return m.errMemory(err)
+	}
+	m.PC += 2
+	return nil
 }
 
 //-----------------------------------------------------------------------------
@@ -2327,16 +2431,15 @@ func emu_C_ADDIW(m *RV, ins uint) error {
 
 func emu_C_LDSP(m *RV, ins uint) error {
 	uimm, rd := decodeCIg(ins)
+	if rd == 0 {
+		return m.errIllegal(ins)
+	}
 	adr := uint(m.rdX(RegSp)) + uimm
 	val, err := m.Mem.Rd64(adr)
 	if err != nil {
 		return m.errMemory(err)
 	}
-	if rd != 0 {
-		m.wrX(rd, val)
-	} else {
-		return m.errIllegal(ins)
-	}
+	m.wrX(rd, val)
 	m.PC += 2
 	return nil
 }
@@ -2384,128 +2487,790 @@ func emu_C_ADDW(m *RV, ins uint) error {
 }
 
 //-----------------------------------------------------------------------------
-// Integer Register Access
+// zbb
 
-// wrX writes an integer register
-func (m *RV) wrX(i uint, val uint64) {
-	if i == 0 {
-		// no writes to zero
-		return
-	}
+func emu_CLZ(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
 	if m.xlen == 32 {
-		val = uint64(uint32(val))
+		m.wrX(rd, uint64(bits.LeadingZeros32(uint32(m.rdX(rs1)))))
+	} else {
+		m.wrX(rd, uint64(bits.LeadingZeros64(m.rdX(rs1))))
 	}
-	m.x[i] = val
+	m.PC += 4
+	return nil
 }
 
-// rdX reads an integer register
-func (m *RV) rdX(i uint) uint64 {
-	if i == 0 {
-		// always reads as zero
-		return 0
-	}
+func emu_CTZ(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
 	if m.xlen == 32 {
-		return uint64(uint32(m.x[i]))
+		m.wrX(rd, uint64(bits.TrailingZeros32(uint32(m.rdX(rs1)))))
+	} else {
+		m.wrX(rd, uint64(bits.TrailingZeros64(m.rdX(rs1))))
 	}
-	return m.x[i]
+	m.PC += 4
+	return nil
 }
 
-//-----------------------------------------------------------------------------
-// Float Register Access
+func emu_CPOP(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(bits.OnesCount64(m.rdX(rs1))))
+	m.PC += 4
+	return nil
+}
 
-// wrFS writes a 32-bit float register.
-func (m *RV) wrFS(i uint, val uint32) {
-	m.f[i] = uint64(val) | upper32
+func emu_CLZW(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(bits.LeadingZeros32(uint32(m.rdX(rs1)))))
+	m.PC += 4
+	return nil
 }
 
-// rdFS reads a 32-bit float register.
-func (m *RV) rdFS(i uint) uint32 {
-	return uint32(m.f[i])
+func emu_CTZW(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(bits.TrailingZeros32(uint32(m.rdX(rs1)))))
+	m.PC += 4
+	return nil
 }
 
-// wrFD writes a 64-bit float register.
-func (m *RV) wrFD(i uint, val uint64) {
-	m.f[i] = val
+func emu_CPOPW(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(bits.OnesCount32(uint32(m.rdX(rs1)))))
+	m.PC += 4
+	return nil
 }
 
-// rdFD reads a 64-bit float register.
-func (m *RV) rdFD(i uint) uint64 {
-	return m.f[i]
+func emu_SEXT_B(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(int8(m.rdX(rs1))))
+	m.PC += 4
+	return nil
 }
 
-//-----------------------------------------------------------------------------
+func emu_SEXT_H(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(int16(m.rdX(rs1))))
+	m.PC += 4
+	return nil
+}
 
-func maxInt32(a, b int32) int32 {
-	if a > b {
-		return a
-	}
-	return b
+func emu_ZEXT_H(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)&0xffff)
+	m.PC += 4
+	return nil
 }
 
-func minInt32(a, b int32) int32 {
-	if a < b {
-		return a
-	}
-	return b
+func emu_ANDN(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)&^m.rdX(rs2))
+	m.PC += 4
+	return nil
 }
 
-func maxUint32(a, b uint32) uint32 {
-	if a > b {
-		return a
-	}
-	return b
+func emu_ORN(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)|^m.rdX(rs2))
+	m.PC += 4
+	return nil
 }
 
-func minUint32(a, b uint32) uint32 {
-	if a < b {
-		return a
-	}
-	return b
+func emu_XNOR(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, ^(m.rdX(rs1) ^ m.rdX(rs2)))
+	m.PC += 4
+	return nil
 }
 
-func maxInt64(a, b int64) int64 {
-	if a > b {
-		return a
+// lessThan returns true if rs1 < rs2 (signed).
+func (m *RV) lessThan(rs1, rs2 uint) bool {
+	if m.xlen == 32 {
+		return int32(m.rdX(rs1)) < int32(m.rdX(rs2))
 	}
-	return b
+	return int64(m.rdX(rs1)) < int64(m.rdX(rs2))
 }
 
-func minInt64(a, b int64) int64 {
-	if a < b {
-		return a
+func emu_MIN(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	if m.lessThan(rs1, rs2) {
+		m.wrX(rd, m.rdX(rs1))
+	} else {
+		m.wrX(rd, m.rdX(rs2))
 	}
-	return b
+	m.PC += 4
+	return nil
 }
 
-func maxUint64(a, b uint64) uint64 {
-	if a > b {
-		return a
+func emu_MINU(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	if m.rdX(rs1) < m.rdX(rs2) {
+		m.wrX(rd, m.rdX(rs1))
+	} else {
+		m.wrX(rd, m.rdX(rs2))
 	}
-	return b
+	m.PC += 4
+	return nil
 }
 
-func minUint64(a, b uint64) uint64 {
-	if a < b {
-		return a
+func emu_MAX(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	if m.lessThan(rs1, rs2) {
+		m.wrX(rd, m.rdX(rs2))
+	} else {
+		m.wrX(rd, m.rdX(rs1))
 	}
-	return b
+	m.PC += 4
+	return nil
 }
 
-//-----------------------------------------------------------------------------
-
-// RV is a RISC-V CPU.
-type RV struct {
-	x      [32]uint64  // integer registers
-	f      [32]uint64  // float registers
-	PC     uint64      // program counter
-	isa    *ISA        // ISA implemented for the CPU
-	Mem    *mem.Memory // memory of the target system
-	CSR    *csr.State  // CSR state
-	amo    sync.Mutex  // lock for atomic operations
-	lastPC uint64      // stuck PC detection
-	xlen   uint        // bit length of integer registers
-	err    *errBuffer  // buffer of handled/un-handled emulation errors
-}
+func emu_MAXU(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	if m.rdX(rs1) < m.rdX(rs2) {
+		m.wrX(rd, m.rdX(rs2))
+	} else {
+		m.wrX(rd, m.rdX(rs1))
+	}
+	m.PC += 4
+	return nil
+}
+
+// rotateLeft rotates an XLEN value left by k bits (right for k < 0).
+func (m *RV) rotateLeft(x uint64, k int) uint64 {
+	if m.xlen == 32 {
+		return uint64(bits.RotateLeft32(uint32(x), k))
+	}
+	return bits.RotateLeft64(x, k)
+}
+
+func emu_ROL(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	shamt := m.rdX(rs2) & uint64(m.xlen-1)
+	m.wrX(rd, m.rotateLeft(m.rdX(rs1), int(shamt)))
+	m.PC += 4
+	return nil
+}
+
+func emu_ROR(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	shamt := m.rdX(rs2) & uint64(m.xlen-1)
+	m.wrX(rd, m.rotateLeft(m.rdX(rs1), -int(shamt)))
+	m.PC += 4
+	return nil
+}
+
+func emu_RORI(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	if m.xlen == 32 && shamt > 31 {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, m.rotateLeft(m.rdX(rs1), -int(shamt)))
+	m.PC += 4
+	return nil
+}
+
+func emu_ROLW(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	shamt := m.rdX(rs2) & 31
+	m.wrX(rd, uint64(int32(bits.RotateLeft32(uint32(m.rdX(rs1)), int(shamt)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_RORW(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	shamt := m.rdX(rs2) & 31
+	m.wrX(rd, uint64(int32(bits.RotateLeft32(uint32(m.rdX(rs1)), -int(shamt)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_RORIW(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	m.wrX(rd, uint64(int32(bits.RotateLeft32(uint32(m.rdX(rs1)), -int(shamt&31)))))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zba
+
+func emu_SH1ADD(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (m.rdX(rs1)<<1)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_SH2ADD(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (m.rdX(rs1)<<2)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_SH3ADD(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (m.rdX(rs1)<<3)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_ADD_UW(m *RV, ins uint) error {
+	if m.xlen == 32 {
+		return m.errIllegal(ins)
+	}
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (m.rdX(rs1)&0xffffffff)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_SLLI_UW(m *RV, ins uint) error {
+	if m.xlen == 32 {
+		return m.errIllegal(ins)
+	}
+	shamt, rs1, rd := decodeIc(ins)
+	m.wrX(rd, (m.rdX(rs1)&0xffffffff)<<shamt)
+	m.PC += 4
+	return nil
+}
+
+func emu_SH1ADD_UW(m *RV, ins uint) error {
+	if m.xlen == 32 {
+		return m.errIllegal(ins)
+	}
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (uint64(uint32(m.rdX(rs1)))<<1)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_SH2ADD_UW(m *RV, ins uint) error {
+	if m.xlen == 32 {
+		return m.errIllegal(ins)
+	}
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (uint64(uint32(m.rdX(rs1)))<<2)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_SH3ADD_UW(m *RV, ins uint) error {
+	if m.xlen == 32 {
+		return m.errIllegal(ins)
+	}
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (uint64(uint32(m.rdX(rs1)))<<3)+m.rdX(rs2))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zbs
+
+// bitIndex returns the single bit mask for the register bit index.
+func (m *RV) bitIndex(rs2 uint) uint64 {
+	return 1 << (m.rdX(rs2) & uint64(m.xlen-1))
+}
+
+// bitImmediate returns the single bit mask for the immediate bit index.
+func (m *RV) bitImmediate(shamt uint) (uint64, bool) {
+	if m.xlen == 32 && shamt > 31 {
+		return 0, false
+	}
+	return 1 << shamt, true
+}
+
+// bitValue returns 1 if the masked bit is set, else 0.
+func bitValue(x, bit uint64) uint64 {
+	if x&bit != 0 {
+		return 1
+	}
+	return 0
+}
+
+func emu_BCLR(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)&^m.bitIndex(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_BCLRI(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	bit, ok := m.bitImmediate(shamt)
+	if !ok {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, m.rdX(rs1)&^bit)
+	m.PC += 4
+	return nil
+}
+
+func emu_BEXT(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, bitValue(m.rdX(rs1), m.bitIndex(rs2)))
+	m.PC += 4
+	return nil
+}
+
+func emu_BEXTI(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	bit, ok := m.bitImmediate(shamt)
+	if !ok {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, bitValue(m.rdX(rs1), bit))
+	m.PC += 4
+	return nil
+}
+
+func emu_BINV(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)^m.bitIndex(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_BINVI(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	bit, ok := m.bitImmediate(shamt)
+	if !ok {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, m.rdX(rs1)^bit)
+	m.PC += 4
+	return nil
+}
+
+func emu_BSET(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.rdX(rs1)|m.bitIndex(rs2))
+	m.PC += 4
+	return nil
+}
+
+func emu_BSETI(m *RV, ins uint) error {
+	shamt, rs1, rd := decodeIc(ins)
+	bit, ok := m.bitImmediate(shamt)
+	if !ok {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, m.rdX(rs1)|bit)
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zbc
+
+// clmul returns the 128-bit carry-less product of a and b.
+func clmul(a, b uint64) (hi, lo uint64) {
+	for i := uint(0); i < 64; i++ {
+		if (b>>i)&1 != 0 {
+			lo ^= a << i
+			if i != 0 {
+				hi ^= a >> (64 - i)
+			}
+		}
+	}
+	return
+}
+
+func emu_CLMULH(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	hi, lo := clmul(m.rdX(rs1), m.rdX(rs2))
+	if m.xlen == 32 {
+		// the 64-bit product is in lo
+		hi = lo >> 32
+	}
+	m.wrX(rd, hi)
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zbkb
+
+func emu_BREV8(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, bits.ReverseBytes64(bits.Reverse64(m.rdX(rs1))))
+	m.PC += 4
+	return nil
+}
+
+func emu_PACK(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	half := m.xlen >> 1
+	mask := uint64(1)<<half - 1
+	m.wrX(rd, (m.rdX(rs1)&mask)|(m.rdX(rs2)&mask)<<half)
+	m.PC += 4
+	return nil
+}
+
+func emu_PACKH(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, (m.rdX(rs1)&0xff)|(m.rdX(rs2)&0xff)<<8)
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zbkx
+
+// xperm looks up each n-bit element of rs2 as an index into the elements of rs1.
+// Out of range indices give 0.
+func (m *RV) xperm(rs1, rs2 uint64, n uint) uint64 {
+	mask := uint64(1)<<n - 1
+	var x uint64
+	for i := uint(0); i < m.xlen; i += n {
+		idx := uint((rs2 >> i) & mask)
+		if idx < m.xlen/n {
+			x |= ((rs1 >> (idx * n)) & mask) << i
+		}
+	}
+	return x
+}
+
+func emu_XPERM4(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.xperm(m.rdX(rs1), m.rdX(rs2), 4))
+	m.PC += 4
+	return nil
+}
+
+func emu_XPERM8(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, m.xperm(m.rdX(rs1), m.rdX(rs2), 8))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zknd/zkne
+
+func emu_AES32DSI(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(aes32(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, &aesInvSbox, nil)))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES32DSMI(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(aes32(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, &aesInvSbox, aesMixInv)))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES32ESI(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(aes32(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, &aesSbox, nil)))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES32ESMI(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(aes32(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, &aesSbox, aesMixFwd)))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES64IM(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, aes64im(m.rdX(rs1)))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES64KS1I(m *RV, ins uint) error {
+	rnum, rs1, rd := decodeIe(ins)
+	if rnum > 10 {
+		return m.errIllegal(ins)
+	}
+	m.wrX(rd, aes64ks1i(m.rdX(rs1), rnum))
+	m.PC += 4
+	return nil
+}
+
+func emu_AES64KS2(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, aes64ks2(m.rdX(rs1), m.rdX(rs2)))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zknh
+
+func emu_SHA512SIG0H(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sig0h(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SHA512SIG0L(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sig0l(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SHA512SIG1H(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sig1h(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SHA512SIG1L(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sig1l(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SHA512SUM0R(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sum0r(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SHA512SUM1R(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(sha512sum1r(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)))))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zksh
+
+func emu_SM3P0(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(int32(sm3p0(uint32(m.rdX(rs1))))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SM3P1(m *RV, ins uint) error {
+	_, rs1, _, rd := decodeR(ins)
+	m.wrX(rd, uint64(int32(sm3p1(uint32(m.rdX(rs1))))))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// zksed
+
+func emu_SM4ED(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(int32(sm4(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, sm4Led))))
+	m.PC += 4
+	return nil
+}
+
+func emu_SM4KS(m *RV, ins uint) error {
+	bs, rs2, rs1, rd := decodeRa(ins)
+	m.wrX(rd, uint64(int32(sm4(uint32(m.rdX(rs1)), uint32(m.rdX(rs2)), bs, sm4Lks))))
+	m.PC += 4
+	return nil
+}
+
+//-----------------------------------------------------------------------------
+// vector
+
+// vsetvl sets the vector length and type.
+func (m *RV) vsetvl(avl uint64, rs1, rd, vtype uint) {
+	if rs1 == 0 {
+		if rd != 0 {
+			// maximum vector length
+			avl = ^uint64(0)
+		} else {
+			// keep the current vector length
+			avl, _ = m.CSR.Rd(csr.VL)
+		}
+	}
+	m.wrX(rd, uint64(m.CSR.SetVectorLength(uint(avl), vtype)))
+}
+
+func emu_VSETVLI(m *RV, ins uint) error {
+	vtypei, rs1, rd := decodeIf(ins)
+	m.vsetvl(m.rdX(rs1), rs1, rd, vtypei)
+	m.PC += 4
+	return nil
+}
+
+func emu_VSETIVLI(m *RV, ins uint) error {
+	vtypei, uimm, rd := decodeIg(ins)
+	m.wrX(rd, uint64(m.CSR.SetVectorLength(uimm, vtypei)))
+	m.PC += 4
+	return nil
+}
+
+func emu_VSETVL(m *RV, ins uint) error {
+	rs2, rs1, _, rd := decodeR(ins)
+	m.vsetvl(m.rdX(rs1), rs1, rd, uint(m.rdX(rs2)))
+	m.PC += 4
+	return nil
+}
+
+func emu_VLE8_V(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VLE16_V(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VLE32_V(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VLE64_V(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VADD_VV(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VADD_VX(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+func emu_VADD_VI(m *RV, ins uint) error {
+	return m.errTodo()
+}
+
+//-----------------------------------------------------------------------------
+// Integer Register Access
+
+// wrX writes an integer register.
+// All integer register writes go through here so that x0 stays hardwired to zero.
+func (m *RV) wrX(i uint, val uint64) {
+	if i == 0 {
+		// no writes to zero
+		return
+	}
+	if m.xlen == 32 {
+		val = uint64(uint32(val))
+	}
+	m.x[i] = val
+}
+
+// rdX reads an integer register
+func (m *RV) rdX(i uint) uint64 {
+	if i == 0 {
+		// always reads as zero
+		return 0
+	}
+	if m.xlen == 32 {
+		return uint64(uint32(m.x[i]))
+	}
+	return m.x[i]
+}
+
+//-----------------------------------------------------------------------------
+// Float Register Access
+
+// wrFS writes a 32-bit float register.
+func (m *RV) wrFS(i uint, val uint32) {
+	m.f[i] = uint64(val) | upper32
+}
+
+// rdFS reads a 32-bit float register.
+func (m *RV) rdFS(i uint) uint32 {
+	return uint32(m.f[i])
+}
+
+// wrFD writes a 64-bit float register.
+func (m *RV) wrFD(i uint, val uint64) {
+	m.f[i] = val
+}
+
+// rdFD reads a 64-bit float register.
+func (m *RV) rdFD(i uint) uint64 {
+	return m.f[i]
+}
+
+//-----------------------------------------------------------------------------
+
+func maxInt32(a, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt32(a, b int32) int32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint32(a, b uint32) uint32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minUint32(a, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+//-----------------------------------------------------------------------------
+
+// RV is a RISC-V CPU.
+type RV struct {
+	x       [32]uint64      // integer registers
+	f       [32]uint64      // float registers
+	PC      uint64          // program counter
+	isa     *ISA            // ISA implemented for the CPU
+	Mem     *mem.Memory     // memory of the target system
+	CSR     *csr.State      // CSR state
+	amo     sync.Mutex      // lock for atomic operations
+	lastPC  uint64          // stuck PC detection
+	xlen    uint            // bit length of integer registers
+	err     *errBuffer      // buffer of handled/un-handled emulation errors
+	profile map[uint]uint64 // instruction execution counts by PC (nil when not profiling)
+	debug   bool            // enable internal consistency checks
+}
 
 // Reset the CPU.
 func (m *RV) Reset() {
@@ -2515,6 +3280,11 @@ func (m *RV) Reset() {
 	m.lastPC = 0
 }
 
+// SetDebug enables/disables the internal consistency checks made after each instruction.
+func (m *RV) SetDebug(on bool) {
+	m.debug = on
+}
+
 // NewRV64 returns a 64-bit RISC-V CPU.
 func NewRV64(isa *ISA, mem *mem.Memory, csr *csr.State) *RV {
 	m := RV{
@@ -2588,11 +3358,20 @@ func (m *RV) Run() error {
 		return m.errHandler(m.errIllegal(ins))
 	}
 
+	if m.profile != nil {
+		m.profile[uint(m.PC)]++
+	}
+
 	err = im.defn.emu(m, ins)
 	if err != nil {
 		return m.errHandler(err)
 	}
 
+	// x0 is hardwired to zero
+	if m.debug && m.x[0] != 0 {
+		panic(fmt.Sprintf("x0 != 0 after \"%s\"", im.name))
+	}
+
 	// Update the CSR registers
 	m.CSR.IncInstructions()
 	m.CSR.IncClockCycles(2)
@@ -2629,8 +3408,28 @@ func (m *RV) FloatRegs() string {
 }
 
 // Disassemble the instruction at the address.
+// Instructions with effects that depend on the CPU state are annotated with that state.
 func (m *RV) Disassemble(addr uint) *Disassembly {
-	return m.isa.Disassemble(m.Mem, addr)
+	da, ins := m.isa.disassemble(m.Mem, addr, m.xlen)
+	if cmt := m.daState(ins); cmt != "" {
+		da.Comment = cmt
+	}
+	return da
+}
+
+// DisassembleRange disassembles the instructions in the [start, end) address range.
+// It stops at the first illegal instruction.
+func (m *RV) DisassembleRange(start, end uint) []*Disassembly {
+	x := []*Disassembly{}
+	for adr := start; adr < end; {
+		da := m.Disassemble(adr)
+		if da.Assembly == "illegal" {
+			break
+		}
+		x = append(x, da)
+		adr += da.Length
+	}
+	return x
 }
 
 //-----------------------------------------------------------------------------
@@ -0,0 +1,756 @@
+//-----------------------------------------------------------------------------
+/*
+
+Emulation Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/deadsy/riscv/csr"
+	"github.com/deadsy/riscv/mem"
+)
+
+//-----------------------------------------------------------------------------
+
+// newTestRV returns a CPU with a program loaded at address 0.
+func newTestRV(t testing.TB, xlen uint, module []ISAModule, prog []uint32) *RV {
+	isa := NewISA(0)
+	err := isa.Add(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := csr.NewState(xlen, isa.GetExtensions())
+	var m *mem.Memory
+	if xlen == 32 {
+		m = mem.NewMem32(s, 0)
+	} else {
+		m = mem.NewMem64(s, 0)
+	}
+	text := mem.NewSection("text", 0, 1<<12, mem.AttrRWX)
+	for i, ins := range prog {
+		text.Wr32(uint(i*4), ins)
+	}
+	err = m.Add(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xlen == 32 {
+		return NewRV32(isa, m, s)
+	}
+	return NewRV64(isa, m, s)
+}
+
+// runTestRV runs n instructions.
+func runTestRV(t *testing.T, m *RV, n int) {
+	for i := 0; i < n; i++ {
+		err := m.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+func Test_X0(t *testing.T) {
+	prog := []uint32{
+		0x00100013, // addi zero,zero,1
+		0x12345037, // lui zero,0x12345
+		0x0040006f, // jal zero,4
+		0xfff00013, // li zero,-1
+	}
+	for xlen, module := range map[uint][]ISAModule{32: ISArv32g, 64: ISArv64g} {
+		m := newTestRV(t, xlen, module, prog)
+		m.SetDebug(true)
+		for i := range prog {
+			runTestRV(t, m, 1)
+			if m.x[0] != 0 || m.rdX(0) != 0 {
+				t.Errorf("rv%d ins %d: x0 = %x, expected 0", xlen, i, m.x[0])
+			}
+		}
+	}
+	// a corrupted x0 is caught in debug mode
+	m := newTestRV(t, 64, ISArv64g, prog)
+	m.SetDebug(true)
+	m.x[0] = 1
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("x0 != 0: no panic")
+		}
+	}()
+	m.Run()
+}
+
+func Test_Profile(t *testing.T) {
+	prog := []uint32{
+		0x00300513, // li a0,3
+		0xfff50513, // addi a0,a0,-1
+		0xfe051ee3, // bnez a0,4
+		0x00000013, // nop
+	}
+	m := newTestRV(t, 64, ISArv64g, prog)
+	m.Mem.AddSymbol("start", 0, 4)
+	m.Mem.AddSymbol("loop", 4, 8)
+	runTestRV(t, m, 1)
+	m.EnableProfiling()
+	runTestRV(t, m, 6)
+	m.DisableProfiling()
+	runTestRV(t, m, 1)
+	if m.ProfileCounts() != nil {
+		t.Errorf("profile counts should be nil when disabled")
+	}
+	m.Reset()
+	m.EnableProfiling()
+	runTestRV(t, m, 8)
+	counts := m.ProfileCounts()
+	expect := map[uint]uint64{0: 1, 4: 3, 8: 3, 12: 1}
+	for pc, n := range expect {
+		if counts[pc] != n {
+			t.Errorf("pc %x: count %d (expected) %d (actual)", pc, n, counts[pc])
+		}
+	}
+	report := m.ProfileReport(1)
+	if !strings.Contains(report, "loop") || strings.Contains(report, "start") {
+		t.Errorf("bad profile report \"%s\"", report)
+	}
+}
+
+func Test_ReturnComment(t *testing.T) {
+	prog := []uint32{
+		0x30200073, // mret
+		0x10200073, // sret
+	}
+	m := newTestRV(t, 64, ISArv64g, prog)
+	m.CSR.Wr(csr.MEPC, 0x80001234)
+	m.CSR.Wr(csr.SEPC, 0x80004000)
+	m.CSR.Wr(csr.MSTATUS, 3<<11|1<<8) // MPP = M, SPP = S
+	expect := []string{
+		"return to 0x80001234, machine mode",
+		"return to 0x80004000, supervisor mode",
+	}
+	for i, cmt := range expect {
+		da := m.Disassemble(uint(i * 4))
+		if da.Comment != cmt {
+			t.Errorf("\"%s\" (expected) \"%s\" (actual)", cmt, da.Comment)
+		}
+	}
+	m.CSR.Wr(csr.MSTATUS, 0) // MPP = U, SPP = U
+	da := m.Disassemble(0)
+	if da.Comment != "return to 0x80001234, user mode" {
+		t.Errorf("bad comment \"%s\"", da.Comment)
+	}
+}
+
+func Test_CSRImmediate(t *testing.T) {
+	prog := []uint32{
+		0x340ad073, // csrwi mscratch,21
+		0x34056573, // csrrsi a0,mscratch,10
+		0x3400f5f3, // csrrci a1,mscratch,1
+		0xf1406673, // csrrsi a2,mhartid,0
+		0xf14076f3, // csrrci a3,mhartid,0
+	}
+	m := newTestRV(t, 64, ISArv64g, prog)
+	runTestRV(t, m, len(prog))
+	if m.PC != uint64(len(prog)*4) {
+		t.Fatalf("pc %x, expected %x", m.PC, len(prog)*4)
+	}
+	mscratch, _ := m.CSR.Rd(0x340)
+	if mscratch != 30 {
+		t.Errorf("mscratch %d, expected 30", mscratch)
+	}
+	if m.rdX(RegA0) != 21 || m.rdX(RegA1) != 31 {
+		t.Errorf("a0 %d, a1 %d, expected 21, 31", m.rdX(RegA0), m.rdX(RegA1))
+	}
+}
+
+func Test_DisassembleRange(t *testing.T) {
+	prog := []uint32{
+		0x00300513, // li a0,3
+		0x05a1058d, // addi a1,a1,3; addi a1,a1,8 (compressed)
+		0x00000013, // nop
+		0x00000000, // illegal
+	}
+	m := newTestRV(t, 64, ISArv64gc, prog)
+	da := m.DisassembleRange(0, 0x100)
+	expect := []string{"li a0,3", "addi a1,a1,3", "addi a1,a1,8", "nop"}
+	if len(da) != len(expect) {
+		t.Fatalf("%d instructions, expected %d", len(da), len(expect))
+	}
+	for i := range expect {
+		if da[i].Assembly != expect[i] {
+			t.Errorf("\"%s\" (expected) \"%s\" (actual)", expect[i], da[i].Assembly)
+		}
+	}
+	if n := len(m.DisassembleRange(0, 6)); n != 2 {
+		t.Errorf("%d instructions, expected 2", n)
+	}
+	// json round trip
+	buf, err := json.Marshal(da)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(buf), `"Instruction":"li a0,3"`) || !strings.Contains(string(buf), `"N":2`) {
+		t.Errorf("bad json %s", buf)
+	}
+	x := []*Disassembly{}
+	err = json.Unmarshal(buf, &x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range da {
+		if *x[i] != *da[i] {
+			t.Errorf("%v (expected) %v (actual)", *da[i], *x[i])
+		}
+	}
+}
+
+func Test_Backtrace(t *testing.T) {
+	for _, xlen := range []uint{32, 64} {
+		module := map[uint][]ISAModule{32: ISArv32g, 64: ISArv64g}[xlen]
+		m := newTestRV(t, xlen, module, []uint32{0x00000013})
+		m.Mem.AddSymbol("main", 0x100, 0x40)
+		m.Mem.AddSymbol("foo", 0x200, 0x10)
+		n := xlen >> 3
+		wr := func(adr, val uint) {
+			if xlen == 32 {
+				m.Mem.Wr32(adr, uint32(val))
+			} else {
+				m.Mem.Wr64(adr, uint64(val))
+			}
+		}
+		// foo (pc 0x208) <- bar (0x310, no symbol) <- main (0x124)
+		m.PC = 0x208
+		m.wrX(RegSp, 0xc00)
+		m.wrX(RegS0, 0xc20)
+		wr(0xc20-n, 0x310)
+		wr(0xc20-2*n, 0xd00)
+		wr(0xd00-n, 0x124)
+		wr(0xd00-2*n, 0)
+		expect := []Frame{
+			{0x208, 0xc00, "foo", 8},
+			{0x310, 0xc20, "foo", 0x110},
+			{0x124, 0xd00, "main", 0x24},
+		}
+		// the frame reads don't trigger memory monitors
+		hits := 0
+		m.Mem.SetWatch(0xc00, 0x200, mem.WatchRead, func(adr, size uint, isWrite bool, val uint64) { hits++ })
+		m.Mem.AddBreakPoint("ra", 0xc20-n, mem.AttrR, nil)
+		frames := m.Backtrace(32)
+		if hits != 0 || m.Mem.GetBreak() != nil {
+			t.Errorf("rv%d: memory monitor triggered by backtrace", xlen)
+		}
+		if len(frames) != len(expect) {
+			t.Fatalf("rv%d: %d frames, expected %d", xlen, len(frames), len(expect))
+		}
+		for i := range expect {
+			if frames[i] != expect[i] {
+				t.Errorf("rv%d: %v (expected) %v (actual)", xlen, expect[i], frames[i])
+			}
+		}
+		if len(m.Backtrace(2)) != 2 {
+			t.Errorf("rv%d: depth not limited", xlen)
+		}
+		// frame pointer outside of memory
+		m.wrX(RegS0, 0x10000)
+		if len(m.Backtrace(32)) != 1 {
+			t.Errorf("rv%d: expected 1 frame", xlen)
+		}
+	}
+}
+
+func Test_Watch(t *testing.T) {
+	prog := []uint32{
+		0x00500513, // li a0,5
+		0x10a02023, // sw a0,256(zero)
+		0x10002583, // lw a1,256(zero)
+		0x10a02223, // sw a0,260(zero)
+	}
+	m := newTestRV(t, 64, ISArv64g, prog)
+	hits := []string{}
+	fn := func(adr, size uint, isWrite bool, val uint64) {
+		hits = append(hits, fmt.Sprintf("%x %d %v %d", adr, size, isWrite, val))
+	}
+	id := m.Mem.SetWatch(0x102, 1, mem.WatchReadWrite, fn)
+	m.Mem.SetWatch(0x104, 4, mem.WatchRead, fn)
+	runTestRV(t, m, len(prog))
+	expect := []string{"100 4 true 5", "100 4 false 5"}
+	if strings.Join(hits, ",") != strings.Join(expect, ",") {
+		t.Errorf("%v (expected) %v (actual)", expect, hits)
+	}
+	if m.Mem.ClearWatch(id) != nil || m.Mem.ClearWatch(id) == nil {
+		t.Errorf("bad watch point clear")
+	}
+}
+
+func Test_BranchTarget(t *testing.T) {
+	prog := []uint32{
+		0x00050463, // beqz a0,8
+		0xfe051ee3, // bnez a0,0
+		0x0000c511, // beqz a0,14 (compressed)
+		0x0000fd65, // bnez a0,4 (compressed)
+		0xff1ff0ef, // jal ra,0
+		0x0000bfd5, // j 8 (compressed)
+	}
+	m := newTestRV(t, 64, ISArv64gc, prog)
+	m.Mem.AddSymbol("start", 0, 8)
+	m.Mem.AddSymbol("loop", 8, 16)
+	expect := []string{"loop", "start", "loop+0xc", "start+0x4", "start", "loop"}
+	for i, cmt := range expect {
+		da := m.Disassemble(uint(i * 4))
+		if da.Comment != cmt {
+			t.Errorf("%s: \"%s\" (expected) \"%s\" (actual)", da.Assembly, cmt, da.Comment)
+		}
+	}
+	// no symbols (stripped binary)
+	m = newTestRV(t, 64, ISArv64gc, prog)
+	expect = []string{"0x8", "0x0", "0x14", "0x4", "0x0", "0x8"}
+	for i, cmt := range expect {
+		da := m.Disassemble(uint(i * 4))
+		if da.Comment != cmt {
+			t.Errorf("%s: \"%s\" (expected) \"%s\" (actual)", da.Assembly, cmt, da.Comment)
+		}
+	}
+}
+
+func Test_Hints(t *testing.T) {
+	prog := []uint32{
+		0x05010005, // addi zero,zero,1; addi a0,a0,0
+		0x60054005, // li zero,1; lui zero,0x1
+		0x80060012, // slli zero,zero,0x4; mv zero,ra
+		0x05059006, // add zero,zero,ra; addi a0,a0,1
+	}
+	m := newTestRV(t, 64, ISArv64gc, prog)
+	expect := []struct {
+		da, hint, cmt string
+	}{
+		{"addi zero,zero,1", "c.hint.addi zero,zero,1", "standard hint"},
+		{"addi a0,a0,0", "c.hint.addi a0,a0,0", "standard hint"},
+		{"li zero,1", "c.hint.li zero,1", "standard hint"},
+		{"lui zero,0x1", "c.hint.lui zero,0x1", "standard hint"},
+		{"slli zero,zero,0x4", "c.hint.slli zero,zero,0x4", "custom hint"},
+		{"mv zero,ra", "c.hint.mv zero,ra", "standard hint"},
+		{"add zero,zero,ra", "c.hint.add zero,zero,ra", "standard hint"},
+		{"addi a0,a0,1", "addi a0,a0,1", ""},
+	}
+	for i, x := range expect {
+		m.isa.RecognizeHints = false
+		da := m.Disassemble(uint(i * 2))
+		if da.Assembly != x.da || da.Comment != "" {
+			t.Errorf("\"%s\" (expected) \"%s\" \"%s\" (actual)", x.da, da.Assembly, da.Comment)
+		}
+		m.isa.RecognizeHints = true
+		da = m.Disassemble(uint(i * 2))
+		if da.Assembly != x.hint || da.Comment != x.cmt {
+			t.Errorf("\"%s\" \"%s\" (expected) \"%s\" \"%s\" (actual)", x.hint, x.cmt, da.Assembly, da.Comment)
+		}
+	}
+}
+
+func Test_Pseudos(t *testing.T) {
+	testCases := []struct {
+		ins          uint32
+		base, pseudo string
+	}{
+		{0x00000013, "addi zero,zero,0", "nop"},
+		{0x00100013, "addi zero,zero,1", "li zero,1"},
+		{0x00500513, "addi a0,zero,5", "li a0,5"},
+		{0x00058513, "addi a0,a1,0", "mv a0,a1"},
+		{0x00158513, "addi a0,a1,1", "addi a0,a1,1"},
+		{0xfff5c513, "xori a0,a1,-1", "not a0,a1"},
+		{0x40b00533, "sub a0,zero,a1", "neg a0,a1"},
+		{0x00008067, "jalr zero,0(ra)", "ret"},
+		{0x000500e7, "jalr ra,0(a0)", "jalr a0"},
+		{0x004500e7, "jalr ra,4(a0)", "jalr 4(a0)"},
+		{0x0080006f, "jal zero,8", "j 8"},
+		{0x00050463, "beq a0,zero,8", "beqz a0,8"},
+		{0x00051463, "bne a0,zero,8", "bnez a0,8"},
+		{0x34051073, "csrrw zero,mscratch,a0", "csrw mscratch,a0"},
+		{0x34002573, "csrrs a0,mscratch,zero", "csrr a0,mscratch"},
+		{0xc0002573, "csrrs a0,cycle,zero", "rdcycle a0"},
+		{0x00215073, "csrrwi zero,frm,2", "fsrmi 2"},
+		{0x00102573, "csrrs a0,fflags,zero", "frflags a0"},
+		{0x00008082, "jr ra", "ret"},
+		{0x00000001, "addi zero,zero,0", "nop"},
+	}
+	for _, v := range testCases {
+		m := newTestRV(t, 32, ISArv32gc, []uint32{v.ins})
+		m.isa.RecognizePseudos = false
+		if da := m.Disassemble(0); da.Assembly != v.base {
+			t.Errorf("%08x: \"%s\" (expected) \"%s\" (actual)", v.ins, v.base, da.Assembly)
+		}
+		m.isa.RecognizePseudos = true
+		if da := m.Disassemble(0); da.Assembly != v.pseudo {
+			t.Errorf("%08x: \"%s\" (expected) \"%s\" (actual)", v.ins, v.pseudo, da.Assembly)
+		}
+	}
+}
+
+func Test_TriggerComment(t *testing.T) {
+	prog := []uint32{
+		0x7a00d073, // csrwi tselect,1
+		0x7a151073, // csrw tdata1,a0
+		0x7a259073, // csrw tdata2,a1
+		0x7a161073, // csrw tdata1,a2
+		0x7a169073, // csrw tdata1,a3
+		0x7a15a073, // csrs tdata1,a1
+	}
+	m := newTestRV(t, 32, ISArv32gc, prog)
+	m.Mem.AddSymbol("main", 0x100, 0x10)
+	m.wrX(RegA0, 0x20000044) // mcontrol, execute, m-mode
+	m.wrX(RegA1, 0x100)
+	m.wrX(RegA2, 0x30000641) // icount, count 1, m/u-mode, debug
+	m.wrX(RegA3, 0xf0000000)
+	expect := []string{
+		"0x7a0 tselect = 1",
+		"0x7a1 tdata1 mcontrol: execute m, match ==, action breakpoint",
+		"0x7a2 tdata2 = 0x100 main",
+		"0x7a1 tdata1 icount: count 1 m u, action debug",
+		"0x7a1 tdata1 disabled",
+		"0x7a1 tdata1",
+	}
+	for i, cmt := range expect {
+		da := m.Disassemble(uint(i * 4))
+		if da.Comment != cmt {
+			t.Errorf("%s: \"%s\" (expected) \"%s\" (actual)", da.Assembly, cmt, da.Comment)
+		}
+	}
+}
+
+func Test_Counters(t *testing.T) {
+	prog := []uint32{
+		0x00000013, // nop
+		0xc0002573, // rdcycle a0
+		0xc02025f3, // rdinstret a1
+		0xc0102673, // rdtime a2
+	}
+	m := newTestRV(t, 64, ISArv64gc, prog)
+	t0 := uint64(time.Now().UnixNano() / 1000)
+	runTestRV(t, m, 4)
+	t1 := uint64(time.Now().UnixNano() / 1000)
+	if m.rdX(RegA0) != 2 || m.rdX(RegA1) != 2 {
+		t.Errorf("cycle %d, instret %d", m.rdX(RegA0), m.rdX(RegA1))
+	}
+	if a2 := m.rdX(RegA2); a2 < t0 || a2 > t1 {
+		t.Errorf("time %d not in [%d, %d]", a2, t0, t1)
+	}
+}
+
+func Test_StackLoadStore(t *testing.T) {
+	// The same encodings are integer on RV64C and float on RV32C.
+	prog := []uint32{
+		0x65a2e42a, // sdsp a0,8(sp); ldsp a1,8(sp) (rv64) fswsp fa0,8(sp); flwsp fa1,8(sp) (rv32)
+		0x6642c82a, // swsp a0,16(sp); ldsp a2,16(sp) (rv64)
+	}
+	// rv64
+	m := newTestRV(t, 64, ISArv64gc, prog)
+	m.wrX(RegSp, 0x800)
+	m.wrX(RegA0, 0x1122334455667788)
+	m.Mem.Wr64(0x810, 0xffffffffffffffff)
+	runTestRV(t, m, 4)
+	if m.rdX(RegA1) != 0x1122334455667788 || m.rdX(RegA2) != 0xffffffff55667788 {
+		t.Errorf("rv64: a1 %x, a2 %x", m.rdX(RegA1), m.rdX(RegA2))
+	}
+	// rv32
+	prog = append(prog[:1], 0x25a2a42a) // fsdsp fa0,8(sp); fldsp fa1,8(sp)
+	m = newTestRV(t, 32, ISArv32gc, prog)
+	m.CSR.Wr(csr.MSTATUS, 1<<13) // FS = initial
+	m.wrX(RegSp, 0x800)
+	m.wrFS(10, 0x3f800000)
+	runTestRV(t, m, 2)
+	if val, _ := m.Mem.Rd32(0x808); val != 0x3f800000 || m.rdFS(11) != 0x3f800000 {
+		t.Errorf("rv32: mem %x, fa1 %x", val, m.rdFS(11))
+	}
+	m.wrFD(10, 0x4000000000000000)
+	runTestRV(t, m, 2)
+	if m.rdFD(11) != 0x4000000000000000 {
+		t.Errorf("rv32: fa1 %x", m.rdFD(11))
+	}
+}
+
+func Test_FloatLoadStore(t *testing.T) {
+	prog := []uint32{
+		0x2250a6d8, // fsd fa4,136(a3); fld fa2,128(a2)
+		0x0000fedc, // fsw fa5,60(a3) (rv32) sd a5,184(a3) (rv64)
+	}
+	for xlen, module := range map[uint][]ISAModule{32: ISArv32gc, 64: ISArv64gc} {
+		m := newTestRV(t, xlen, module, prog)
+		m.CSR.Wr(csr.MSTATUS, 1<<13) // FS = initial
+		m.wrX(RegA2, 0x400)
+		m.wrX(RegA3, 0x3f8)
+		m.wrFD(14, 0x4000000000000000)
+		m.wrFS(15, 0x3f800000)
+		m.wrX(RegA5, 0x12345678)
+		runTestRV(t, m, 3)
+		if m.rdFD(12) != 0x4000000000000000 {
+			t.Errorf("rv%d: fa2 %x", xlen, m.rdFD(12))
+		}
+		if xlen == 32 {
+			val, _ := m.Mem.Rd32(0x3f8 + 60)
+			if val != 0x3f800000 {
+				t.Errorf("rv32: fsw %x", val)
+			}
+		} else {
+			val, _ := m.Mem.Rd64(0x3f8 + 184)
+			if val != 0x12345678 {
+				t.Errorf("rv64: sd %x", val)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+// bit manipulation
+
+func Test_ZeroExtend(t *testing.T) {
+	testCases := []struct {
+		xlen   uint
+		module []ISAModule
+		ins    uint32
+		base   string
+	}{
+		{32, append([]ISAModule{ISArv32zbbOnly}, ISArv32gc...), 0x0805c533, "pack a0,a1,zero"},
+		{64, append([]ISAModule{ISArv64zbb}, ISArv64gc...), 0x0805c53b, "packw a0,a1,zero"},
+	}
+	for _, v := range testCases {
+		m := newTestRV(t, v.xlen, v.module, []uint32{v.ins})
+		if da := m.Disassemble(0); da.Assembly != "zext.h a0,a1" {
+			t.Errorf("rv%d: \"%s\"", v.xlen, da.Assembly)
+		}
+		m.isa.RecognizePseudos = false
+		if da := m.Disassemble(0); da.Assembly != v.base {
+			t.Errorf("rv%d: \"%s\" (expected) \"%s\" (actual)", v.xlen, v.base, da.Assembly)
+		}
+		m.wrX(RegA1, 0x8765c321)
+		runTestRV(t, m, 1)
+		if m.rdX(RegA0) != 0xc321 {
+			t.Errorf("rv%d: a0 %x", v.xlen, m.rdX(RegA0))
+		}
+	}
+}
+
+// regVal is a register and its value.
+type regVal struct {
+	reg uint   // register number
+	val uint64 // register value
+}
+
+// emuTest is an emulation test case.
+type emuTest struct {
+	xlen   uint        // cpu xlen
+	module []ISAModule // extension modules (added before the base ISA)
+	prog   []uint32    // instructions to run
+	in     []regVal    // initial integer registers
+	out    []regVal    // expected integer registers
+	csr    []regVal    // expected CSRs
+}
+
+var emulationTest = []emuTest{
+	// sext.b a0,a1; sext.h s1,t0
+	{32, []ISAModule{ISArv32zbb}, []uint32{0x60459513, 0x60529493}, []regVal{{RegA1, 0x12345680}, {RegT0, 0x12348001}}, []regVal{{RegA0, 0xffffff80}, {RegS1, 0xffff8001}}, nil},
+	{64, []ISAModule{ISArv32zbb}, []uint32{0x60459513, 0x60529493}, []regVal{{RegA1, 0x12345680}, {RegT0, 0x12348001}}, []regVal{{RegA0, 0xffffffffffffff80}, {RegS1, 0xffffffffffff8001}}, nil},
+	// rol a0,a1,a2; ror a3,a1,a2; rori a4,a1,0x4
+	{32, []ISAModule{ISArv32zbb}, []uint32{0x60c59533, 0x60c5d6b3, 0x6045d713}, []regVal{{RegA1, 0x12345678}, {RegA2, 4}}, []regVal{{RegA0, 0x23456781}, {RegA3, 0x81234567}, {RegA4, 0x81234567}}, nil},
+	{64, []ISAModule{ISArv32zbb}, []uint32{0x60c59533, 0x60c5d6b3, 0x6045d713}, []regVal{{RegA1, 0x12345678}, {RegA2, 4}}, []regVal{{RegA0, 0x0000000123456780}, {RegA3, 0x8000000001234567}, {RegA4, 0x8000000001234567}}, nil},
+	// rolw a0,a1,a2; roriw a3,a1,0x4
+	{64, []ISAModule{ISArv64zbb}, []uint32{0x60c5953b, 0x6045d69b}, []regVal{{RegA1, 0xf2345678}, {RegA2, 36}}, []regVal{{RegA0, 0x000000002345678f}, {RegA3, 0xffffffff8f234567}}, nil},
+	// clz a0,a1; ctz a3,a1; cpop a4,a1; clz a5,a2; ctz a6,a2
+	{32, []ISAModule{ISArv32zbb}, []uint32{0x60059513, 0x60159693, 0x60259713, 0x60061793, 0x60161813}, []regVal{{RegA1, 0x12345670}}, []regVal{{RegA0, 3}, {RegA3, 4}, {RegA4, 12}, {RegA5, 32}, {RegA6, 32}}, nil},
+	{64, []ISAModule{ISArv32zbb}, []uint32{0x60059513, 0x60159693, 0x60259713, 0x60061793, 0x60161813}, []regVal{{RegA1, 0x12345670}}, []regVal{{RegA0, 35}, {RegA3, 4}, {RegA4, 12}, {RegA5, 64}, {RegA6, 64}}, nil},
+	// clzw a0,a1; ctzw a3,a1; cpopw a4,a1
+	{64, []ISAModule{ISArv64zbb}, []uint32{0x6005951b, 0x6015969b, 0x6025971b}, []regVal{{RegA1, 0xffffffff00001000}}, []regVal{{RegA0, 19}, {RegA3, 12}, {RegA4, 1}}, nil},
+	// max a0,a1,a2; min a3,a1,a2; minu a4,a1,a2; maxu a5,a1,a2 (a1 = INT_MIN, a2 = 0)
+	{32, []ISAModule{ISArv32zbb}, []uint32{0x0ac5e533, 0x0ac5c6b3, 0x0ac5d733, 0x0ac5f7b3}, []regVal{{RegA1, 1 << 31}, {RegA2, 0}}, []regVal{{RegA0, 0}, {RegA3, 1 << 31}, {RegA4, 0}, {RegA5, 1 << 31}}, nil},
+	{64, []ISAModule{ISArv32zbb}, []uint32{0x0ac5e533, 0x0ac5c6b3, 0x0ac5d733, 0x0ac5f7b3}, []regVal{{RegA1, 1 << 63}, {RegA2, 0}}, []regVal{{RegA0, 0}, {RegA3, 1 << 63}, {RegA4, 0}, {RegA5, 1 << 63}}, nil},
+	// andn a0,a1,a2; orn a3,a1,a2; xnor a4,a1,a2
+	{32, []ISAModule{ISArv32zbb}, []uint32{0x40c5f533, 0x40c5e6b3, 0x40c5c733}, []regVal{{RegA1, 0x000000ff}, {RegA2, 0x00000ff0}}, []regVal{{RegA0, 0x0000000f}, {RegA3, 0xfffff0ff}, {RegA4, 0xfffff0f0}}, nil},
+	{64, []ISAModule{ISArv32zbb}, []uint32{0x40c5f533, 0x40c5e6b3, 0x40c5c733}, []regVal{{RegA1, 0x000000ff}, {RegA2, 0x00000ff0}}, []regVal{{RegA0, 0x0000000f}, {RegA3, 0xfffffffffffff0ff}, {RegA4, 0xfffffffffffff0f0}}, nil},
+	// sh1add a0,a1,a2; sh2add a3,a1,a2; sh3add a4,a1,a2
+	{32, []ISAModule{ISArv32zba}, []uint32{0x20c5a533, 0x20c5c6b3, 0x20c5e733}, []regVal{{RegA1, 0x80000001}, {RegA2, 0x1000 - 1}}, []regVal{{RegA0, 0x00001001}, {RegA3, 0x00001003}, {RegA4, 0x00001007}}, nil},
+	{64, []ISAModule{ISArv32zba}, []uint32{0x20c5a533, 0x20c5c6b3, 0x20c5e733}, []regVal{{RegA1, 0x80000001}, {RegA2, 0x1000 - 1}}, []regVal{{RegA0, 0x0000000100001001}, {RegA3, 0x0000000200001003}, {RegA4, 0x0000000400001007}}, nil},
+	// sh1add.uw a0,a1,a2; sh2add.uw a3,a1,a2; sh3add.uw a4,a1,a2
+	{64, []ISAModule{ISArv64zba}, []uint32{0x20c5a53b, 0x20c5c6bb, 0x20c5e73b}, []regVal{{RegA1, 0xffffffff80000001}, {RegA2, 0x1000 - 1}}, []regVal{{RegA0, 0x0000000100001001}, {RegA3, 0x0000000200001003}, {RegA4, 0x0000000400001007}}, nil},
+	// add.uw a0,a1,a2; slli.uw a3,a1,0x21
+	{64, []ISAModule{ISArv64zba}, []uint32{0x08c5853b, 0x0a15969b}, []regVal{{RegA1, 0xffffffff80000001}, {RegA2, 1}}, []regVal{{RegA0, 0x80000002}, {RegA3, 0x0000000200000000}}, nil},
+	// rv64 only
+	{32, []ISAModule{ISArv64zba}, []uint32{0x08c5853b}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	{32, []ISAModule{ISArv64zba}, []uint32{0x0a15969b}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	{32, []ISAModule{ISArv64zba}, []uint32{0x20c5a53b}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	{32, []ISAModule{ISArv64zba}, []uint32{0x20c5c6bb}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	{32, []ISAModule{ISArv64zba}, []uint32{0x20c5e73b}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	// bset a0,a1,a2; bclr a3,a1,a2; binv a4,a1,a2; bext a5,a1,a2 (index 33 = bit 1 on rv32)
+	{32, []ISAModule{ISArv32zbs}, []uint32{0x28c59533, 0x48c596b3, 0x68c59733, 0x48c5d7b3}, []regVal{{RegA2, 33}, {RegA1, 0x11}}, []regVal{{RegA0, 0x13}, {RegA3, 0x11}, {RegA4, 0x13}, {RegA5, 0}}, nil},
+	{64, []ISAModule{ISArv32zbs}, []uint32{0x28c59533, 0x48c596b3, 0x68c59733, 0x48c5d7b3}, []regVal{{RegA2, 33}, {RegA1, 0x11}}, []regVal{{RegA0, 0x200000011}, {RegA3, 0x11}, {RegA4, 0x200000011}, {RegA5, 0}}, nil},
+	// bseti s2,a1,0x1f; bclri s3,a1,0x0; binvi s4,a1,0x4; bexti s5,a1,0x4
+	{32, []ISAModule{ISArv32zbs}, []uint32{0x29f59913, 0x48059993, 0x68459a13, 0x4845da93}, []regVal{{RegA1, 0x11}}, []regVal{{RegS2, 0x80000011}, {RegS3, 0x10}, {RegS4, 0x01}, {RegS5, 1}}, nil},
+	{64, []ISAModule{ISArv32zbs}, []uint32{0x29f59913, 0x48059993, 0x68459a13, 0x4845da93}, []regVal{{RegA1, 0x11}}, []regVal{{RegS2, 0x80000011}, {RegS3, 0x10}, {RegS4, 0x01}, {RegS5, 1}}, nil},
+	// bseti s6,a1,0x20
+	{64, []ISAModule{ISArv32zbs}, []uint32{0x2a059b13}, []regVal{{RegA1, 0x11}}, []regVal{{RegS6, 0x100000011}}, nil},
+	// shamt[5] is reserved on rv32
+	{32, []ISAModule{ISArv32zbs}, []uint32{0x2a059b13}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	// clmulh a0,a1,a2; clmulh a3,a4,a5
+	// operands are the halves of H and C from test case 2 of the AES-GCM
+	// specification (see Test_GHASH)
+	{32, []ISAModule{ISArv32zbc}, []uint32{0x0ac5b533, 0x0af736b3}, []regVal{{RegA1, 0x66e94bd4}, {RegA2, 0x0388dace}, {RegA4, 0xca342b2e}, {RegA5, 0x71b2fe78}}, []regVal{{RegA0, 0x009b5741}, {RegA3, 0x27d3d433}}, nil},
+	{64, []ISAModule{ISArv32zbc}, []uint32{0x0ac5b533, 0x0af736b3}, []regVal{{RegA1, 0x66e94bd4ef8a2c3b}, {RegA2, 0x0388dace60b6a392}, {RegA4, 0x884cfa59ca342b2e}, {RegA5, 0xf328c2b971b2fe78}}, []regVal{{RegA0, 0x009b5741881e0789}, {RegA3, 0x7e35eadec1d9bcc4}}, nil},
+	// pack a0,a1,a2; packh a3,a1,a2; brev8 a4,a1
+	{32, []ISAModule{ISArv32zbkb}, []uint32{0x08c5c533, 0x08c5f6b3, 0x6875d713}, []regVal{{RegA1, 0x12345678}, {RegA2, 0x9abcdef0}}, []regVal{{RegA0, 0xdef05678}, {RegA3, 0xf078}, {RegA4, 0x482c6a1e}}, nil},
+	{64, []ISAModule{ISArv32zbkb}, []uint32{0x08c5c533, 0x08c5f6b3, 0x6875d713}, []regVal{{RegA1, 0x0123456789abcdef}, {RegA2, 0xfedcba9876543210}}, []regVal{{RegA0, 0x7654321089abcdef}, {RegA3, 0x10ef}, {RegA4, 0x80c4a2e691d5b3f7}}, nil},
+	// xperm4 a0,a1,a2; xperm8 a3,a1,a3 (a1 = lookup table)
+	{32, []ISAModule{ISArv32zbkx}, []uint32{0x28c5a533, 0x28d5c6b3}, []regVal{{RegA1, 0x89abcdef}, {RegA2, 0x0123456f}, {RegA3, 0x00030802}}, []regVal{{RegA0, 0xfedcba90}, {RegA3, 0xef8900ab}}, nil},
+	{64, []ISAModule{ISArv32zbkx}, []uint32{0x28c5a533, 0x28d5c6b3}, []regVal{{RegA1, 0x0123456789abcdef}, {RegA2, 0x0123456789abcdef}, {RegA3, 0x0a08070605040302}}, []regVal{{RegA0, 0xfedcba9876543210}, {RegA3, 0x00000123456789ab}}, nil},
+	// FIPS 197 Appendix C.1 (AES-128), one column of a round per row
+	// a0 = round key column, a1..a4 = round input columns starting at the column
+	// aes32esmi a0,a0,{a1,a2,a3,a4},0..3 (round 1, encrypt)
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x26b50533, 0x66c50533, 0xa6d50533, 0xe6e50533}, []regVal{{RegA0, 0xfd74aad6}, {RegA1, 0x30201000}, {RegA2, 0x70605040}, {RegA3, 0xb0a09080}, {RegA4, 0xf0e0d0c0}}, []regVal{{RegA0, 0xe810d889}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x26b50533, 0x66c50533, 0xa6d50533, 0xe6e50533}, []regVal{{RegA0, 0xfa72afd2}, {RegA1, 0x70605040}, {RegA2, 0xb0a09080}, {RegA3, 0xf0e0d0c0}, {RegA4, 0x30201000}}, []regVal{{RegA0, 0x68ce5a85}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x26b50533, 0x66c50533, 0xa6d50533, 0xe6e50533}, []regVal{{RegA0, 0xf178a6da}, {RegA1, 0xb0a09080}, {RegA2, 0xf0e0d0c0}, {RegA3, 0x30201000}, {RegA4, 0x70605040}}, []regVal{{RegA0, 0xd843182d}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x26b50533, 0x66c50533, 0xa6d50533, 0xe6e50533}, []regVal{{RegA0, 0xfe76abd6}, {RegA1, 0xf0e0d0c0}, {RegA2, 0x30201000}, {RegA3, 0x70605040}, {RegA4, 0xb0a09080}}, []regVal{{RegA0, 0xe48f12cb}}, nil},
+	// aes32esi a0,a0,{a1,a2,a3,a4},0..3 (round 10, encrypt)
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x22b50533, 0x62c50533, 0xa2d50533, 0xe2e50533}, []regVal{{RegA0, 0x7f1d1113}, {RegA1, 0x3d7c6ebd}, {RegA2, 0x9e77b5f2}, {RegA3, 0x6e21610b}, {RegA4, 0x89b6108b}}, []regVal{{RegA0, 0xd8e0c469}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x22b50533, 0x62c50533, 0xa2d50533, 0xe2e50533}, []regVal{{RegA0, 0x174a94e3}, {RegA1, 0x9e77b5f2}, {RegA2, 0x6e21610b}, {RegA3, 0x89b6108b}, {RegA4, 0x3d7c6ebd}}, []regVal{{RegA0, 0x30047b6a}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x22b50533, 0x62c50533, 0xa2d50533, 0xe2e50533}, []regVal{{RegA0, 0x8ba707f3}, {RegA1, 0x6e21610b}, {RegA2, 0x89b6108b}, {RegA3, 0x3d7c6ebd}, {RegA4, 0x9e77b5f2}}, []regVal{{RegA0, 0x80b7cdd8}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x22b50533, 0x62c50533, 0xa2d50533, 0xe2e50533}, []regVal{{RegA0, 0xc5302b4d}, {RegA1, 0x89b6108b}, {RegA2, 0x3d7c6ebd}, {RegA3, 0x9e77b5f2}, {RegA4, 0x6e21610b}}, []regVal{{RegA0, 0x5ac5b470}}, nil},
+	// aes32dsmi a0,a0,{a1,a4,a3,a2},0..3 (round 1, equivalent inverse cipher)
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2eb50533, 0x6ee50533, 0xaed50533, 0xeec50533}, []regVal{{RegA0, 0xbe29aa13}, {RegA1, 0xa7fdd57a}, {RegA2, 0x274eef89}, {RegA3, 0x0b10ca2b}, {RegA4, 0x9ff59f3d}}, []regVal{{RegA0, 0xa190d954}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2eb50533, 0x6ee50533, 0xaed50533, 0xeec50533}, []regVal{{RegA0, 0xf6af8f9c}, {RegA1, 0x274eef89}, {RegA2, 0x0b10ca2b}, {RegA3, 0x9ff59f3d}, {RegA4, 0xa7fdd57a}}, []regVal{{RegA0, 0xb59aa06b}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2eb50533, 0x6ee50533, 0xaed50533, 0xeec50533}, []regVal{{RegA0, 0x80f570f7}, {RegA1, 0x0b10ca2b}, {RegA2, 0x9ff59f3d}, {RegA3, 0xa7fdd57a}, {RegA4, 0x274eef89}}, []regVal{{RegA0, 0x0ef4bb96}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2eb50533, 0x6ee50533, 0xaed50533, 0xeec50533}, []regVal{{RegA0, 0x03bff700}, {RegA1, 0x9ff59f3d}, {RegA2, 0xa7fdd57a}, {RegA3, 0x274eef89}, {RegA4, 0x0b10ca2b}}, []regVal{{RegA0, 0x2f7011a1}}, nil},
+	// aes32dsi a0,a0,{a1,a4,a3,a2},0..3 (round 10, inverse cipher)
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2ab50533, 0x6ae50533, 0xaad50533, 0xeac50533}, []regVal{{RegA0, 0x03020100}, {RegA1, 0x8ce05363}, {RegA2, 0x04e16009}, {RegA3, 0x51b770cd}, {RegA4, 0xe7d0caba}}, []regVal{{RegA0, 0x33221100}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2ab50533, 0x6ae50533, 0xaad50533, 0xeac50533}, []regVal{{RegA0, 0x07060504}, {RegA1, 0x04e16009}, {RegA2, 0x51b770cd}, {RegA3, 0xe7d0caba}, {RegA4, 0x8ce05363}}, []regVal{{RegA0, 0x77665544}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2ab50533, 0x6ae50533, 0xaad50533, 0xeac50533}, []regVal{{RegA0, 0x0b0a0908}, {RegA1, 0x51b770cd}, {RegA2, 0xe7d0caba}, {RegA3, 0x8ce05363}, {RegA4, 0x04e16009}}, []regVal{{RegA0, 0xbbaa9988}}, nil},
+	{32, []ISAModule{ISArv32zknd, ISArv32zkne}, []uint32{0x2ab50533, 0x6ae50533, 0xaad50533, 0xeac50533}, []regVal{{RegA0, 0x0f0e0d0c}, {RegA1, 0xe7d0caba}, {RegA2, 0x8ce05363}, {RegA3, 0x04e16009}, {RegA4, 0x51b770cd}}, []regVal{{RegA0, 0xffeeddcc}}, nil},
+	// aes64ks1i a2,a1,0; aes64ks2 a0,a2,a0; aes64ks2 a1,a0,a1
+	// FIPS 197 Appendix C.1 (AES-128) round key 1
+	{64, []ISAModule{ISArv64zknd}, []uint32{0x31059613, 0x7ea60533, 0x7eb505b3}, []regVal{{RegA0, 0x0706050403020100}, {RegA1, 0x0f0e0d0c0b0a0908}}, []regVal{{RegA0, 0xfa72afd2fd74aad6}, {RegA1, 0xfe76abd6f178a6da}}, nil},
+	// aes64im a0,a1 (decryption key for round 9)
+	{64, []ISAModule{ISArv64zknd}, []uint32{0x30059513}, []regVal{{RegA1, 0x4e972cbe9ced9310}}, []regVal{{RegA0, 0x03bff70080f570f7}}, nil},
+	// aes64ks1i a2,a1,11 (rnum > 10 is reserved)
+	{64, []ISAModule{ISArv64zknd}, []uint32{0x31b59613}, nil, nil, []regVal{{csr.MCAUSE, uint64(csr.ExInsIllegal)}}},
+	// sha512 low word: rs1 = a0 (lo), rs2 = a1 (hi), high word: rs1 = a1 (hi), rs2 = a0 (lo)
+	// sha512sig0l/h, sigma0(W[0]) for the message "abc"
+	{32, []ISAModule{ISArv32zknh}, []uint32{0x54b50633, 0x5ca586b3}, []regVal{{RegA0, 0x00000000}, {RegA1, 0x61626380}}, []regVal{{RegA2, 0x80000000}, {RegA3, 0x30129764}}, nil},
+	// sha512sig1l/h, sigma1(W[0]) for the message "abc"
+	{32, []ISAModule{ISArv32zknh}, []uint32{0x56b50633, 0x5ea586b3}, []regVal{{RegA0, 0x00000000}, {RegA1, 0x61626380}}, []regVal{{RegA2, 0x4c700003}, {RegA3, 0x0a9699a2}}, nil},
+	// sha512sum0r, Sigma0(H[0]) for the initial hash value
+	{32, []ISAModule{ISArv32zknh}, []uint32{0x50b50633, 0x50a586b3}, []regVal{{RegA0, 0xf3bcc908}, {RegA1, 0x6a09e667}}, []regVal{{RegA2, 0xaac80c2a}, {RegA3, 0x08c4db56}}, nil},
+	// sha512sum1r, Sigma1(H[4]) for the initial hash value
+	{32, []ISAModule{ISArv32zknh}, []uint32{0x52b50633, 0x52a586b3}, []regVal{{RegA0, 0xade682d1}, {RegA1, 0x510e527f}}, []regVal{{RegA2, 0xb5c9dbca}, {RegA3, 0x9427e33b}}, nil},
+	// sm3p0 a0,a1; sm3p1 a2,a1
+	{32, []ISAModule{ISArv32zksh}, []uint32{0x10859513, 0x10959613}, []regVal{{RegA1, 0x12345678}}, []regVal{{RegA0, 0xd6688234}, {RegA2, 0x05014549}}, nil},
+	{64, []ISAModule{ISArv32zksh}, []uint32{0x10859513, 0x10959613}, []regVal{{RegA1, 0x12345678}}, []regVal{{RegA0, 0xffffffffd6688234}, {RegA2, 0x05014549}}, nil},
+	// GB/T 32907-2016 example 1, the first round of the key schedule and cipher
+	// (byte-reversed, the instructions use little-endian words)
+	// a0 = X0, a1 = X1^X2^X3^K, result = X4
+	// sm4ks a0,a0,a1,0..3
+	{32, []ISAModule{ISArv32zksed}, []uint32{0x34b50533, 0x74b50533, 0xb4b50533, 0xf4b50533}, []regVal{{RegA0, 0xa1ff92a2}, {RegA1, 0x69cb8382}}, []regVal{{RegA0, 0xf98621f1}}, nil},
+	{64, []ISAModule{ISArv32zksed}, []uint32{0x34b50533, 0x74b50533, 0xb4b50533, 0xf4b50533}, []regVal{{RegA0, 0xa1ff92a2}, {RegA1, 0x69cb8382}}, []regVal{{RegA0, 0xfffffffff98621f1}}, nil},
+	// sm4ed a0,a0,a1,0..3
+	{32, []ISAModule{ISArv32zksed}, []uint32{0x30b50533, 0x70b50533, 0xb0b50533, 0xf0b50533}, []regVal{{RegA0, 0x67452301}, {RegA1, 0x9ec302f0}}, []regVal{{RegA0, 0x45d3fa27}}, nil},
+	{64, []ISAModule{ISArv32zksed}, []uint32{0x30b50533, 0x70b50533, 0xb0b50533, 0xf0b50533}, []regVal{{RegA0, 0x67452301}, {RegA1, 0x9ec302f0}}, []regVal{{RegA0, 0x45d3fa27}}, nil},
+	// vector configuration (VLEN = 128), a1 = avl, a2 = vtype
+	// vsetvli a0,a1,e32,m4,ta,ma (vlmax 16)
+	{32, []ISAModule{ISArv32v}, []uint32{0x0d25f557}, []regVal{{RegA1, 10}}, []regVal{{RegA0, 10}}, []regVal{{csr.VL, 10}, {csr.VTYPE, 0xd2}}},
+	{64, []ISAModule{ISArv32v}, []uint32{0x0d25f557}, []regVal{{RegA1, 100}}, []regVal{{RegA0, 16}}, []regVal{{csr.VL, 16}, {csr.VTYPE, 0xd2}}},
+	// vsetvli a0,a1,e8,mf8,ta,ma (vlmax 2)
+	{64, []ISAModule{ISArv32v}, []uint32{0x0c55f557}, []regVal{{RegA1, 100}}, []regVal{{RegA0, 2}}, []regVal{{csr.VL, 2}, {csr.VTYPE, 0xc5}}},
+	// vsetvli a0,a1,e64,mf8,ta,ma (unsupported)
+	{32, []ISAModule{ISArv32v}, []uint32{0x0dd5f557}, []regVal{{RegA1, 100}}, []regVal{{RegA0, 0}}, []regVal{{csr.VL, 0}, {csr.VTYPE, 1 << 31}}},
+	// vsetvli a0,zero,e32,m4,ta,ma (avl = vlmax)
+	{64, []ISAModule{ISArv32v}, []uint32{0x0d207557}, []regVal{{RegA1, 0}}, []regVal{{RegA0, 16}}, []regVal{{csr.VL, 16}, {csr.VTYPE, 0xd2}}},
+	// vsetvli a0,a1,e32,m4,ta,ma; vsetvli zero,zero,e16,m2,ta,ma (keep vl)
+	{64, []ISAModule{ISArv32v}, []uint32{0x0d25f557, 0x0c907057}, []regVal{{RegA1, 10}}, nil, []regVal{{csr.VL, 10}, {csr.VTYPE, 0xc9}}},
+	// vsetivli a0,5,e16,m1,tu,mu
+	{32, []ISAModule{ISArv32v}, []uint32{0xc082f557}, []regVal{{RegA1, 0}}, []regVal{{RegA0, 5}}, []regVal{{csr.VL, 5}, {csr.VTYPE, 0x08}}},
+	// vsetivli a0,31,e64,m1,tu,mu (vlmax 2)
+	{64, []ISAModule{ISArv32v}, []uint32{0xc18ff557}, []regVal{{RegA1, 0}}, []regVal{{RegA0, 2}}, []regVal{{csr.VL, 2}, {csr.VTYPE, 0x18}}},
+	// vsetvl a0,a1,a2
+	{64, []ISAModule{ISArv32v}, []uint32{0x80c5f557}, []regVal{{RegA1, 7}, {RegA2, 0x0b}}, []regVal{{RegA0, 7}}, []regVal{{csr.VL, 7}, {csr.VTYPE, 0x0b}}},
+	{64, []ISAModule{ISArv32v}, []uint32{0x80c5f557}, []regVal{{RegA1, 7}, {RegA2, 0x20}}, []regVal{{RegA0, 0}}, []regVal{{csr.VL, 0}, {csr.VTYPE, 1 << 63}}},
+}
+
+func Test_Emulation(t *testing.T) {
+	for i, v := range emulationTest {
+		module := map[uint][]ISAModule{32: ISArv32gc, 64: ISArv64gc}[v.xlen]
+		m := newTestRV(t, v.xlen, append(append([]ISAModule{}, v.module...), module...), v.prog)
+		for _, x := range v.in {
+			m.wrX(x.reg, x.val)
+		}
+		runTestRV(t, m, len(v.prog))
+		for _, x := range v.out {
+			if m.rdX(x.reg) != x.val {
+				t.Errorf("case %d (rv%d %08x): %s %x (expected) %x (actual)", i, v.xlen, v.prog[0], abiXName[x.reg], x.val, m.rdX(x.reg))
+			}
+		}
+		for _, x := range v.csr {
+			if val, _ := m.CSR.Rd(x.reg); val != x.val {
+				t.Errorf("case %d (rv%d %08x): %s %x (expected) %x (actual)", i, v.xlen, v.prog[0], csr.Name(x.reg), x.val, val)
+			}
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// ghashMul returns the GHASH product of x and y (big-endian 128-bit blocks).
+// The high halves of the partial products are computed with clmulh, there is
+// no clmul instruction so the low halves use the emulator's clmul function.
+func ghashMul(t *testing.T, x, y [2]uint64) [2]uint64 {
+	mul := func(a, b uint64) (uint64, uint64) {
+		m := newTestRV(t, 64, append([]ISAModule{ISArv32zbc}, ISArv64gc...), []uint32{
+			0x0ac5b533, // clmulh a0,a1,a2
+		})
+		m.wrX(RegA1, a)
+		m.wrX(RegA2, b)
+		runTestRV(t, m, 1)
+		_, lo := clmul(a, b)
+		return m.rdX(RegA0), lo
+	}
+	// GHASH blocks are bit-reflected
+	a := [2]uint64{bits.Reverse64(x[1]), bits.Reverse64(x[0])} // hi, lo
+	b := [2]uint64{bits.Reverse64(y[1]), bits.Reverse64(y[0])}
+	// 256-bit product
+	var p [4]uint64
+	hi, lo := mul(a[1], b[1])
+	p[0] ^= lo
+	p[1] ^= hi
+	hi, lo = mul(a[0], b[1])
+	p[1] ^= lo
+	p[2] ^= hi
+	hi, lo = mul(a[1], b[0])
+	p[1] ^= lo
+	p[2] ^= hi
+	hi, lo = mul(a[0], b[0])
+	p[2] ^= lo
+	p[3] ^= hi
+	// reduce modulo x^128 + x^7 + x^2 + x + 1
+	hi, lo = mul(p[3], 0x87)
+	p[1] ^= lo
+	p[2] ^= hi
+	hi, lo = mul(p[2], 0x87)
+	p[0] ^= lo
+	p[1] ^= hi
+	return [2]uint64{bits.Reverse64(p[0]), bits.Reverse64(p[1])}
+}
+
+func Test_GHASH(t *testing.T) {
+	// AES-GCM specification test case 2
+	h := [2]uint64{0x66e94bd4ef8a2c3b, 0x884cfa59ca342b2e}
+	c := [2]uint64{0x0388dace60b6a392, 0xf328c2b971b2fe78}
+	x1 := ghashMul(t, c, h)
+	if x1 != [2]uint64{0x5e2ec74691706288, 0x2c85b0685353deb7} {
+		t.Errorf("X1 %016x%016x", x1[0], x1[1])
+	}
+}
+
+//-----------------------------------------------------------------------------
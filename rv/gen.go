@@ -105,6 +105,16 @@ var knownFields = map[string]int{
 	"rm":                         3,
 	"aq":                         1,
 	"rl":                         1,
+	"bs":                         2,
+	"rnum":                       4,
+	"zimm[10:0]":                 11,
+	"zimm[9:0]":                  10,
+	"uimm[4:0]":                  5,
+	"vm":                         1,
+	"vd":                         5,
+	"vs1":                        5,
+	"vs2":                        5,
+	"simm5":                      5,
 	"imm[11|4|9:8|10|6|7|3:1|5]": 11,
 	"imm[7:6|2:1|5]":             5,
 	"imm[8|4:3]":                 3,
@@ -179,6 +189,14 @@ var knownDecodes = map[string]decodeType{
 	"5b_aq_rl_5b_rs1_3b_rd_7b":                decodeTypeR,
 	"5b_aq_rl_rs2_rs1_3b_rd_7b":               decodeTypeR,
 	"7b_5b_rs1_3b_rd_7b":                      decodeTypeR,
+	"bs_5b_rs2_rs1_3b_rd_7b":                  decodeTypeR,
+	"6b_vm_vs2_vs1_3b_vd_7b":                  decodeTypeR,
+	"6b_vm_vs2_rs1_3b_vd_7b":                  decodeTypeR,
+	"6b_vm_vs2_simm5_3b_vd_7b":                decodeTypeR,
+	"8b_rnum_rs1_3b_rd_7b":                    decodeTypeI,
+	"1b_zimm[10:0]_rs1_3b_rd_7b":              decodeTypeI,
+	"2b_zimm[9:0]_uimm[4:0]_3b_rd_7b":         decodeTypeI,
+	"6b_vm_5b_rs1_3b_vd_7b":                   decodeTypeI,
 	"rs3_2b_rs2_rs1_rm_rd_7b":                 decodeTypeR4,
 	"3b_nzuimm[5:4|9:6|2|3]_rd0_2b":           decodeTypeCIW,
 	"3b_8b_3b_2b":                             decodeTypeCIW,
@@ -195,6 +213,8 @@ var knownDecodes = map[string]decodeType{
 	"3b_nzuimm[5]_2b_rs10/rd0_nzuimm[4:0]_2b": decodeTypeCI,
 	"3b_imm[5]_2b_rs10/rd0_imm[4:0]_2b":       decodeTypeCI,
 	"3b_1b_2b_rs10/rd0_2b_rs20_2b":            decodeTypeCR,
+	"3b_1b_2b_rs10/rd0_nzuimm[4:0]_2b":        decodeTypeCI,
+	"3b_1b_rs1/rd!=0_nzuimm[4:0]_2b":          decodeTypeCI,
 	"3b_imm[8|4:3]_rs10_imm[7:6|2:1|5]_2b":    decodeTypeCB,
 	"3b_nzuimm[5]_rs1/rd!=0_nzuimm[4:0]_2b":   decodeTypeCI,
 	"3b_1b_rs1/rd!=0_5b_2b":                   decodeTypeCI,
@@ -226,6 +246,10 @@ func nameRemap(name string) string {
 		"c.swsp":     "sw",
 		"c.ldsp":     "ld",
 		"c.sdsp":     "sd",
+		"c.flwsp":    "flw",
+		"c.fswsp":    "fsw",
+		"c.fldsp":    "fld",
+		"c.fsdsp":    "fsd",
 		"c.addi16sp": "addi",
 		"c.addi4spn": "addi",
 	}
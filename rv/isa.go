@@ -10,6 +10,7 @@ package rv
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/deadsy/riscv/csr"
@@ -45,60 +46,61 @@ var ISArv32i = ISAModule{
 	ext:  csr.IsaExtI,
 	ilen: 32,
 	defn: []insDefn{
-		{"imm[31:12] rd 0110111 LUI", daTypeUa, emu_LUI},                             // U
-		{"imm[31:12] rd 0010111 AUIPC", daTypeUa, emu_AUIPC},                         // U
-		{"imm[20|10:1|11|19:12] rd 1101111 JAL", daTypeJa, emu_JAL},                  // J
-		{"imm[11:0] rs1 000 rd 1100111 JALR", daTypeIe, emu_JALR},                    // I
-		{"imm[12|10:5] rs2 rs1 000 imm[4:1|11] 1100011 BEQ", daTypeBa, emu_BEQ},      // B
-		{"imm[12|10:5] rs2 rs1 001 imm[4:1|11] 1100011 BNE", daTypeBa, emu_BNE},      // B
-		{"imm[12|10:5] rs2 rs1 100 imm[4:1|11] 1100011 BLT", daTypeBa, emu_BLT},      // B
-		{"imm[12|10:5] rs2 rs1 101 imm[4:1|11] 1100011 BGE", daTypeBa, emu_BGE},      // B
-		{"imm[12|10:5] rs2 rs1 110 imm[4:1|11] 1100011 BLTU", daTypeBa, emu_BLTU},    // B
-		{"imm[12|10:5] rs2 rs1 111 imm[4:1|11] 1100011 BGEU", daTypeBa, emu_BGEU},    // B
-		{"imm[11:0] rs1 000 rd 0000011 LB", daTypeIc, emu_LB},                        // I
-		{"imm[11:0] rs1 001 rd 0000011 LH", daTypeIc, emu_LH},                        // I
-		{"imm[11:0] rs1 010 rd 0000011 LW", daTypeIc, emu_LW},                        // I
-		{"imm[11:0] rs1 100 rd 0000011 LBU", daTypeIc, emu_LBU},                      // I
-		{"imm[11:0] rs1 101 rd 0000011 LHU", daTypeIc, emu_LHU},                      // I
-		{"imm[11:5] rs2 rs1 000 imm[4:0] 0100011 SB", daTypeSa, emu_SB},              // S
-		{"imm[11:5] rs2 rs1 001 imm[4:0] 0100011 SH", daTypeSa, emu_SH},              // S
-		{"imm[11:5] rs2 rs1 010 imm[4:0] 0100011 SW", daTypeSa, emu_SW},              // S
-		{"imm[11:0] rs1 000 rd 0010011 ADDI", daTypeIb, emu_ADDI},                    // I
-		{"imm[11:0] rs1 010 rd 0010011 SLTI", daTypeIa, emu_SLTI},                    // I
-		{"imm[11:0] rs1 011 rd 0010011 SLTIU", daTypeIa, emu_SLTIU},                  // I
-		{"imm[11:0] rs1 100 rd 0010011 XORI", daTypeIf, emu_XORI},                    // I
-		{"imm[11:0] rs1 110 rd 0010011 ORI", daTypeIa, emu_ORI},                      // I
-		{"imm[11:0] rs1 111 rd 0010011 ANDI", daTypeIa, emu_ANDI},                    // I
-		{"000000 shamt6 rs1 001 rd 0010011 SLLI", daTypeId, emu_SLLI},                // I
-		{"000000 shamt6 rs1 101 rd 0010011 SRLI", daTypeId, emu_SRLI},                // I
-		{"010000 shamt6 rs1 101 rd 0010011 SRAI", daTypeId, emu_SRAI},                // I
-		{"0000000 rs2 rs1 000 rd 0110011 ADD", daTypeRa, emu_ADD},                    // R
-		{"0100000 rs2 rs1 000 rd 0110011 SUB", daTypeRa, emu_SUB},                    // R
-		{"0000000 rs2 rs1 001 rd 0110011 SLL", daTypeRa, emu_SLL},                    // R
-		{"0000000 rs2 rs1 010 rd 0110011 SLT", daTypeRa, emu_SLT},                    // R
-		{"0000000 rs2 rs1 011 rd 0110011 SLTU", daTypeRa, emu_SLTU},                  // R
-		{"0000000 rs2 rs1 100 rd 0110011 XOR", daTypeRa, emu_XOR},                    // R
-		{"0000000 rs2 rs1 101 rd 0110011 SRL", daTypeRa, emu_SRL},                    // R
-		{"0100000 rs2 rs1 101 rd 0110011 SRA", daTypeRa, emu_SRA},                    // R
-		{"0000000 rs2 rs1 110 rd 0110011 OR", daTypeRa, emu_OR},                      // R
-		{"0000000 rs2 rs1 111 rd 0110011 AND", daTypeRa, emu_AND},                    // R
-		{"0000 pred succ 00000 000 00000 0001111 FENCE", daTypeIi, emu_FENCE},        // I
-		{"0000 0000 0000 00000 001 00000 0001111 FENCE.I", daTypeIi, emu_FENCE_I},    // I
-		{"0000000 00000 00000 000 00000 1110011 ECALL", daTypeIi, emu_ECALL},         // I
-		{"0000000 00001 00000 000 00000 1110011 EBREAK", daTypeIi, emu_EBREAK},       // I
-		{"0000000 00010 00000 000 00000 1110011 URET", daTypeIi, emu_URET},           // I
-		{"0001000 00010 00000 000 00000 1110011 SRET", daTypeIi, emu_SRET},           // I
-		{"0011000 00010 00000 000 00000 1110011 MRET", daTypeIi, emu_MRET},           // I
-		{"0001000 00101 00000 000 00000 1110011 WFI", daTypeIi, emu_WFI},             // I
-		{"0001001 rs2 rs1 000 00000 1110011 SFENCE.VMA", daTypeIk, emu_SFENCE_VMA},   // I
-		{"0010001 rs2 rs1 000 00000 1110011 HFENCE.BVMA", daTypeIk, emu_HFENCE_BVMA}, // I
-		{"1010001 rs2 rs1 000 00000 1110011 HFENCE.GVMA", daTypeIk, emu_HFENCE_GVMA}, // I
-		{"csr rs1 001 rd 1110011 CSRRW", daTypeIh, emu_CSRRW},                        // I
-		{"csr rs1 010 rd 1110011 CSRRS", daTypeIh, emu_CSRRS},                        // I
-		{"csr rs1 011 rd 1110011 CSRRC", daTypeIh, emu_CSRRC},                        // I
-		{"csr zimm 101 rd 1110011 CSRRWI", daTypeIj, emu_CSRRWI},                     // I
-		{"csr zimm 110 rd 1110011 CSRRSI", daTypeIj, emu_CSRRSI},                     // I
-		{"csr zimm 111 rd 1110011 CSRRCI", daTypeIj, emu_CSRRCI},                     // I
+		{"imm[31:12] rd 0110111 LUI", daTypeUa, emu_LUI},                              // U
+		{"imm[31:12] rd 0010111 AUIPC", daTypeUa, emu_AUIPC},                          // U
+		{"imm[20|10:1|11|19:12] rd 1101111 JAL", daTypeJa, emu_JAL},                   // J
+		{"imm[11:0] rs1 000 rd 1100111 JALR", daTypeIe, emu_JALR},                     // I
+		{"imm[12|10:5] rs2 rs1 000 imm[4:1|11] 1100011 BEQ", daTypeBa, emu_BEQ},       // B
+		{"imm[12|10:5] rs2 rs1 001 imm[4:1|11] 1100011 BNE", daTypeBa, emu_BNE},       // B
+		{"imm[12|10:5] rs2 rs1 100 imm[4:1|11] 1100011 BLT", daTypeBa, emu_BLT},       // B
+		{"imm[12|10:5] rs2 rs1 101 imm[4:1|11] 1100011 BGE", daTypeBa, emu_BGE},       // B
+		{"imm[12|10:5] rs2 rs1 110 imm[4:1|11] 1100011 BLTU", daTypeBa, emu_BLTU},     // B
+		{"imm[12|10:5] rs2 rs1 111 imm[4:1|11] 1100011 BGEU", daTypeBa, emu_BGEU},     // B
+		{"imm[11:0] rs1 000 rd 0000011 LB", daTypeIc, emu_LB},                         // I
+		{"imm[11:0] rs1 001 rd 0000011 LH", daTypeIc, emu_LH},                         // I
+		{"imm[11:0] rs1 010 rd 0000011 LW", daTypeIc, emu_LW},                         // I
+		{"imm[11:0] rs1 100 rd 0000011 LBU", daTypeIc, emu_LBU},                       // I
+		{"imm[11:0] rs1 101 rd 0000011 LHU", daTypeIc, emu_LHU},                       // I
+		{"imm[11:5] rs2 rs1 000 imm[4:0] 0100011 SB", daTypeSa, emu_SB},               // S
+		{"imm[11:5] rs2 rs1 001 imm[4:0] 0100011 SH", daTypeSa, emu_SH},               // S
+		{"imm[11:5] rs2 rs1 010 imm[4:0] 0100011 SW", daTypeSa, emu_SW},               // S
+		{"imm[11:0] rs1 000 rd 0010011 ADDI", daTypeIa, emu_ADDI},                     // I
+		{"imm[11:0] rs1 010 rd 0010011 SLTI", daTypeIa, emu_SLTI},                     // I
+		{"imm[11:0] rs1 011 rd 0010011 SLTIU", daTypeIa, emu_SLTIU},                   // I
+		{"imm[11:0] rs1 100 rd 0010011 XORI", daTypeIa, emu_XORI},                     // I
+		{"imm[11:0] rs1 110 rd 0010011 ORI", daTypeIa, emu_ORI},                       // I
+		{"imm[11:0] rs1 111 rd 0010011 ANDI", daTypeIa, emu_ANDI},                     // I
+		{"000000 shamt6 rs1 001 rd 0010011 SLLI", daTypeId, emu_SLLI},                 // I
+		{"000000 shamt6 rs1 101 rd 0010011 SRLI", daTypeId, emu_SRLI},                 // I
+		{"010000 shamt6 rs1 101 rd 0010011 SRAI", daTypeId, emu_SRAI},                 // I
+		{"0000000 rs2 rs1 000 rd 0110011 ADD", daTypeRa, emu_ADD},                     // R
+		{"0100000 rs2 rs1 000 rd 0110011 SUB", daTypeRa, emu_SUB},                     // R
+		{"0000000 rs2 rs1 001 rd 0110011 SLL", daTypeRa, emu_SLL},                     // R
+		{"0000000 rs2 rs1 010 rd 0110011 SLT", daTypeRa, emu_SLT},                     // R
+		{"0000000 rs2 rs1 011 rd 0110011 SLTU", daTypeRa, emu_SLTU},                   // R
+		{"0000000 rs2 rs1 100 rd 0110011 XOR", daTypeRa, emu_XOR},                     // R
+		{"0000000 rs2 rs1 101 rd 0110011 SRL", daTypeRa, emu_SRL},                     // R
+		{"0100000 rs2 rs1 101 rd 0110011 SRA", daTypeRa, emu_SRA},                     // R
+		{"0000000 rs2 rs1 110 rd 0110011 OR", daTypeRa, emu_OR},                       // R
+		{"0000000 rs2 rs1 111 rd 0110011 AND", daTypeRa, emu_AND},                     // R
+		{"1000 0011 0011 00000 000 00000 0001111 FENCE.TSO", daTypeIi, emu_FENCE_TSO}, // I
+		{"0000 pred succ 00000 000 00000 0001111 FENCE", daTypeIi, emu_FENCE},         // I
+		{"0000 0000 0000 00000 001 00000 0001111 FENCE.I", daTypeIi, emu_FENCE_I},     // I
+		{"0000000 00000 00000 000 00000 1110011 ECALL", daTypeIi, emu_ECALL},          // I
+		{"0000000 00001 00000 000 00000 1110011 EBREAK", daTypeIi, emu_EBREAK},        // I
+		{"0000000 00010 00000 000 00000 1110011 URET", daTypeIi, emu_URET},            // I
+		{"0001000 00010 00000 000 00000 1110011 SRET", daTypeIi, emu_SRET},            // I
+		{"0011000 00010 00000 000 00000 1110011 MRET", daTypeIi, emu_MRET},            // I
+		{"0001000 00101 00000 000 00000 1110011 WFI", daTypeIi, emu_WFI},              // I
+		{"0001001 rs2 rs1 000 00000 1110011 SFENCE.VMA", daTypeIk, emu_SFENCE_VMA},    // I
+		{"0010001 rs2 rs1 000 00000 1110011 HFENCE.BVMA", daTypeIk, emu_HFENCE_BVMA},  // I
+		{"1010001 rs2 rs1 000 00000 1110011 HFENCE.GVMA", daTypeIk, emu_HFENCE_GVMA},  // I
+		{"csr rs1 001 rd 1110011 CSRRW", daTypeIh, emu_CSRRW},                         // I
+		{"csr rs1 010 rd 1110011 CSRRS", daTypeIh, emu_CSRRS},                         // I
+		{"csr rs1 011 rd 1110011 CSRRC", daTypeIh, emu_CSRRC},                         // I
+		{"csr zimm 101 rd 1110011 CSRRWI", daTypeIj, emu_CSRRWI},                      // I
+		{"csr zimm 110 rd 1110011 CSRRSI", daTypeIj, emu_CSRRSI},                      // I
+		{"csr zimm 111 rd 1110011 CSRRCI", daTypeIj, emu_CSRRCI},                      // I
 	},
 }
 
@@ -219,8 +221,8 @@ var ISArv32c = ISAModule{
 		{"010 imm[5] rd!=0 imm[4:0] 01 C.LI", daTypeCIa, emu_C_LI},                       // CI
 		{"011 nzimm[9] 00010 nzimm[4|6|8:7|5] 01 C.ADDI16SP", daTypeCIb, emu_C_ADDI16SP}, // CI
 		{"011 nzimm[17] rd!={0,2} nzimm[16:12] 01 C.LUI", daTypeCIg, emu_C_LUI},          // CI
-		{"100 nzuimm[5] 00 rs10/rd0 nzuimm[4:0] 01 C.SRLI", daTypeCId, emu_C_SRLI},       // CI
-		{"100 nzuimm[5] 01 rs10/rd0 nzuimm[4:0] 01 C.SRAI", daTypeCId, emu_C_SRAI},       // CI
+		{"100 0 00 rs10/rd0 nzuimm[4:0] 01 C.SRLI", daTypeCId, emu_C_SRLI},               // CI
+		{"100 0 01 rs10/rd0 nzuimm[4:0] 01 C.SRAI", daTypeCId, emu_C_SRAI},               // CI
 		{"100 imm[5] 10 rs10/rd0 imm[4:0] 01 C.ANDI", daTypeCIf, emu_C_ANDI},             // CI
 		{"100 0 11 rs10/rd0 00 rs20 01 C.SUB", daTypeCRc, emu_C_SUB},                     // CR
 		{"100 0 11 rs10/rd0 01 rs20 01 C.XOR", daTypeCRc, emu_C_XOR},                     // CR
@@ -229,14 +231,13 @@ var ISArv32c = ISAModule{
 		{"101 imm[11|4|9:8|10|6|7|3:1|5] 01 C.J", daTypeCJb, emu_C_J},                    // CJ
 		{"110 imm[8|4:3] rs10 imm[7:6|2:1|5] 01 C.BEQZ", daTypeCBa, emu_C_BEQZ},          // CB
 		{"111 imm[8|4:3] rs10 imm[7:6|2:1|5] 01 C.BNEZ", daTypeCBa, emu_C_BNEZ},          // CB
-		{"000 nzuimm[5] rs1/rd!=0 nzuimm[4:0] 10 C.SLLI", daTypeCIe, emu_C_SLLI},         // CI (Quadrant 2)
-		{"000 0 rs1/rd!=0 00000 10 C.SLLI64", daNone, emu_C_SLLI64},                      // CI
+		{"000 0 rs1/rd!=0 nzuimm[4:0] 10 C.SLLI", daTypeCIe, emu_C_SLLI},                 // CI (Quadrant 2)
 		{"010 uimm[5] rd!=0 uimm[4:2|7:6] 10 C.LWSP", daTypeCSSa, emu_C_LWSP},            // CSS
 		{"100 0 rs1!=0 00000 10 C.JR", daTypeCRd, emu_C_JR},                              // CR
-		{"100 0 rd!=0 rs2!=0 10 C.MV", daTypeCRa, emu_C_MV},                              // CR
-		{"100 1 00000 00000 10 C.EBREAK", daNone, emu_C_EBREAK},                          // CI
-		{"100 1 rs1!=0 00000 10 C.JALR", daTypeCRe, emu_C_JALR},                          // CR
-		{"100 1 rs1/rd!=0 rs2!=0 10 C.ADD", daTypeCRb, emu_C_ADD},                        // CR
+		{"100 0 rd!=0 rs2!=0 10 C.MV", daTypeCRd, emu_C_MV},                              // CR
+		{"100 1 00000 00000 10 C.EBREAK", daTypeCRd, emu_C_EBREAK},                       // CI
+		{"100 1 rs1!=0 00000 10 C.JALR", daTypeCRd, emu_C_JALR},                          // CR
+		{"100 1 rs1/rd!=0 rs2!=0 10 C.ADD", daTypeCRd, emu_C_ADD},                        // CR
 		{"110 uimm[5:2|7:6] rs2 10 C.SWSP", daTypeCSSb, emu_C_SWSP},                      // CSS
 	},
 }
@@ -246,7 +247,10 @@ var ISArv32cOnly = ISAModule{
 	ext:  csr.IsaExtC,
 	ilen: 16,
 	defn: []insDefn{
-		{"001 imm[11|4|9:8|10|6|7|3:1|5] 01 C.JAL", daTypeCJc, emu_C_JAL}, // CJ
+		{"001 imm[11|4|9:8|10|6|7|3:1|5] 01 C.JAL", daTypeCJc, emu_C_JAL},      // CJ
+		{"100 1 00 rs10/rd0 nzuimm[4:0] 01 C.SRLI", daReserved, emu_C_ILLEGAL}, // CI (shamt[5] = 1 is reserved)
+		{"100 1 01 rs10/rd0 nzuimm[4:0] 01 C.SRAI", daReserved, emu_C_ILLEGAL}, // CI
+		{"000 1 rs1/rd!=0 nzuimm[4:0] 10 C.SLLI", daReserved, emu_C_ILLEGAL},   // CI
 	},
 }
 
@@ -256,9 +260,9 @@ var ISArv32fc = ISAModule{
 	ilen: 16,
 	defn: []insDefn{
 		{"011 uimm[5:3] rs10 uimm[2|6] rd0 00 C.FLW", daTypeCSc, emu_C_FLW},  // CL
-		{"011 uimm[5] rd uimm[4:2|7:6] 10 C.FLWSP", daNone, emu_C_FLWSP},     // CSS
+		{"011 uimm[5] rd uimm[4:2|7:6] 10 C.FLWSP", daTypeCSSd, emu_C_FLWSP}, // CSS
 		{"111 uimm[5:3] rs10 uimm[2|6] rs20 00 C.FSW", daTypeCSc, emu_C_FSW}, // CS
-		{"111 uimm[5:2|7:6] rs2 10 C.FSWSP", daNone, emu_C_FSWSP},            // CSS
+		{"111 uimm[5:2|7:6] rs2 10 C.FSWSP", daTypeCSSe, emu_C_FSWSP},        // CSS
 	},
 }
 
@@ -267,10 +271,10 @@ var ISArv32dc = ISAModule{
 	ext:  csr.IsaExtC,
 	ilen: 16,
 	defn: []insDefn{
-		{"001 uimm[5:3] rs10 uimm[7:6] rd0 00 C.FLD", daTypeCSc, emu_C_FLD},  // CL
-		{"001 uimm[5] rd uimm[4:3|8:6] 10 C.FLDSP", daNone, emu_C_FLDSP},     // CSS
-		{"101 uimm[5:3] rs10 uimm[7:6] rs20 00 C.FSD", daTypeCSc, emu_C_FSD}, // CS
-		{"101 uimm[5:3|8:6] rs2 10 C.FSDSP", daNone, emu_C_FSDSP},            // CSS
+		{"001 uimm[5:3] rs10 uimm[7:6] rd0 00 C.FLD", daTypeCSd, emu_C_FLD},  // CL
+		{"001 uimm[5] rd uimm[4:3|8:6] 10 C.FLDSP", daTypeCIi, emu_C_FLDSP},  // CSS
+		{"101 uimm[5:3] rs10 uimm[7:6] rs20 00 C.FSD", daTypeCSd, emu_C_FSD}, // CS
+		{"101 uimm[5:3|8:6] rs2 10 C.FSDSP", daTypeCSSf, emu_C_FSDSP},        // CSS
 	},
 }
 
@@ -366,9 +370,12 @@ var ISArv64c = ISAModule{
 		{"001 imm[5] rd!=0 imm[4:0] 01 C.ADDIW", daTypeCIc, emu_C_ADDIW},   // CI
 		{"011 uimm[5] rd uimm[4:3|8:6] 10 C.LDSP", daTypeCIh, emu_C_LDSP},  // CI
 		{"011 uimm[5:3] rs10 uimm[7:6] rd0 00 C.LD", daTypeCSb, emu_C_LD},  // CL
+		{"100 1 00 rs10/rd0 nzuimm[4:0] 01 C.SRLI", daTypeCId, emu_C_SRLI}, // CI (shamt[5] = 1)
+		{"100 1 01 rs10/rd0 nzuimm[4:0] 01 C.SRAI", daTypeCId, emu_C_SRAI}, // CI
 		{"100 1 11 rs10/rd0 00 rs20 01 C.SUBW", daTypeCRc, emu_C_SUBW},     // CR
 		{"100 1 11 rs10/rd0 01 rs20 01 C.ADDW", daTypeCRc, emu_C_ADDW},     // CR
 		{"111 uimm[5:3] rs10 uimm[7:6] rs20 00 C.SD", daTypeCSb, emu_C_SD}, // CS
+		{"000 1 rs1/rd!=0 nzuimm[4:0] 10 C.SLLI", daTypeCIe, emu_C_SLLI},   // CI
 		{"111 uimm[5:3|8:6] rs2 10 C.SDSP", daTypeCSSc, emu_C_SDSP},        // CSS
 	},
 }
@@ -386,6 +393,235 @@ var ISArv128c = ISAModule{
 	},
 }
 
+//-----------------------------------------------------------------------------
+// Vector (RV32 + RV64)
+
+// ISArv32v Vector Instructions
+var ISArv32v = ISAModule{
+	ext:  csr.IsaExtV,
+	ilen: 32,
+	defn: []insDefn{
+		{"0 zimm[10:0] rs1 111 rd 1010111 VSETVLI", daTypeIm, emu_VSETVLI},         // I
+		{"11 zimm[9:0] uimm[4:0] 111 rd 1010111 VSETIVLI", daTypeIn, emu_VSETIVLI}, // I
+		{"1000000 rs2 rs1 111 rd 1010111 VSETVL", daTypeRa, emu_VSETVL},            // R
+		{"000000 vm 00000 rs1 000 vd 0000111 VLE8.V", daTypeIo, emu_VLE8_V},        // I
+		{"000000 vm 00000 rs1 101 vd 0000111 VLE16.V", daTypeIo, emu_VLE16_V},      // I
+		{"000000 vm 00000 rs1 110 vd 0000111 VLE32.V", daTypeIo, emu_VLE32_V},      // I
+		{"000000 vm 00000 rs1 111 vd 0000111 VLE64.V", daTypeIo, emu_VLE64_V},      // I
+		{"000000 vm vs2 vs1 000 vd 1010111 VADD.VV", daTypeRo, emu_VADD_VV},        // R
+		{"000000 vm vs2 rs1 100 vd 1010111 VADD.VX", daTypeRp, emu_VADD_VX},        // R
+		{"000000 vm vs2 simm5 011 vd 1010111 VADD.VI", daTypeRq, emu_VADD_VI},      // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Bit Manipulation (RV32 + RV64)
+
+// ISArv32zbb Basic Bit Manipulation
+var ISArv32zbb = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0110000 00000 rs1 001 rd 0010011 CLZ", daTypeRl, emu_CLZ},       // R
+		{"0110000 00001 rs1 001 rd 0010011 CTZ", daTypeRl, emu_CTZ},       // R
+		{"0110000 00010 rs1 001 rd 0010011 CPOP", daTypeRl, emu_CPOP},     // R
+		{"0110000 00100 rs1 001 rd 0010011 SEXT.B", daTypeRl, emu_SEXT_B}, // R
+		{"0110000 00101 rs1 001 rd 0010011 SEXT.H", daTypeRl, emu_SEXT_H}, // R
+		{"0100000 rs2 rs1 111 rd 0110011 ANDN", daTypeRa, emu_ANDN},       // R
+		{"0100000 rs2 rs1 110 rd 0110011 ORN", daTypeRa, emu_ORN},         // R
+		{"0100000 rs2 rs1 100 rd 0110011 XNOR", daTypeRa, emu_XNOR},       // R
+		{"0000101 rs2 rs1 100 rd 0110011 MIN", daTypeRa, emu_MIN},         // R
+		{"0000101 rs2 rs1 101 rd 0110011 MINU", daTypeRa, emu_MINU},       // R
+		{"0000101 rs2 rs1 110 rd 0110011 MAX", daTypeRa, emu_MAX},         // R
+		{"0000101 rs2 rs1 111 rd 0110011 MAXU", daTypeRa, emu_MAXU},       // R
+		{"0110000 rs2 rs1 001 rd 0110011 ROL", daTypeRa, emu_ROL},         // R
+		{"0110000 rs2 rs1 101 rd 0110011 ROR", daTypeRa, emu_ROR},         // R
+		{"011000 shamt6 rs1 101 rd 0010011 RORI", daTypeId, emu_RORI},     // I
+	},
+}
+
+// ISArv32zbbOnly Basic Bit Manipulation (RV32 only)
+var ISArv32zbbOnly = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0000100 00000 rs1 100 rd 0110011 ZEXT.H", daTypeRm, emu_ZEXT_H}, // R
+	},
+}
+
+// ISArv32zba Address Generation
+var ISArv32zba = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0010000 rs2 rs1 010 rd 0110011 SH1ADD", daTypeRa, emu_SH1ADD}, // R
+		{"0010000 rs2 rs1 100 rd 0110011 SH2ADD", daTypeRa, emu_SH2ADD}, // R
+		{"0010000 rs2 rs1 110 rd 0110011 SH3ADD", daTypeRa, emu_SH3ADD}, // R
+	},
+}
+
+// ISArv32zbs Single-Bit Instructions
+var ISArv32zbs = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0100100 rs2 rs1 001 rd 0110011 BCLR", daTypeRa, emu_BCLR},     // R
+		{"010010 shamt6 rs1 001 rd 0010011 BCLRI", daTypeId, emu_BCLRI}, // I
+		{"0100100 rs2 rs1 101 rd 0110011 BEXT", daTypeRa, emu_BEXT},     // R
+		{"010010 shamt6 rs1 101 rd 0010011 BEXTI", daTypeId, emu_BEXTI}, // I
+		{"0110100 rs2 rs1 001 rd 0110011 BINV", daTypeRa, emu_BINV},     // R
+		{"011010 shamt6 rs1 001 rd 0010011 BINVI", daTypeId, emu_BINVI}, // I
+		{"0010100 rs2 rs1 001 rd 0110011 BSET", daTypeRa, emu_BSET},     // R
+		{"001010 shamt6 rs1 001 rd 0010011 BSETI", daTypeId, emu_BSETI}, // I
+	},
+}
+
+// ISArv32zbc Carry-Less Multiplication
+var ISArv32zbc = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0000101 rs2 rs1 011 rd 0110011 CLMULH", daTypeRa, emu_CLMULH}, // R
+	},
+}
+
+// ISArv32zbkb Bit Manipulation for Cryptography
+// Note: pack rd,rs1,zero is zext.h on rv32, so ISArv32zbbOnly must be added first.
+var ISArv32zbkb = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0110100 00111 rs1 101 rd 0010011 BREV8", daTypeRl, emu_BREV8}, // I
+		{"0000100 rs2 rs1 100 rd 0110011 PACK", daTypeRa, emu_PACK},     // R
+		{"0000100 rs2 rs1 111 rd 0110011 PACKH", daTypeRa, emu_PACKH},   // R
+	},
+}
+
+// ISArv32zbkx Crossbar Permutations
+var ISArv32zbkx = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0010100 rs2 rs1 010 rd 0110011 XPERM4", daTypeRa, emu_XPERM4}, // R
+		{"0010100 rs2 rs1 100 rd 0110011 XPERM8", daTypeRa, emu_XPERM8}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Scalar Cryptography (RV32 + RV64)
+
+// ISArv32zksh SM3 Hash Functions
+var ISArv32zksh = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"0001000 01000 rs1 001 rd 0010011 SM3P0", daTypeRl, emu_SM3P0}, // I
+		{"0001000 01001 rs1 001 rd 0010011 SM3P1", daTypeRl, emu_SM3P1}, // I
+	},
+}
+
+// ISArv32zksed SM4 Block Cipher
+var ISArv32zksed = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 11000 rs2 rs1 000 rd 0110011 SM4ED", daTypeRn, emu_SM4ED}, // R
+		{"bs 11010 rs2 rs1 000 rd 0110011 SM4KS", daTypeRn, emu_SM4KS}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Scalar Cryptography (RV32 only)
+
+// ISArv32zknd AES Decryption
+var ISArv32zknd = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 10101 rs2 rs1 000 rd 0110011 AES32DSI", daTypeRn, emu_AES32DSI},   // R
+		{"bs 10111 rs2 rs1 000 rd 0110011 AES32DSMI", daTypeRn, emu_AES32DSMI}, // R
+	},
+}
+
+// ISArv32zkne AES Encryption
+var ISArv32zkne = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"bs 10001 rs2 rs1 000 rd 0110011 AES32ESI", daTypeRn, emu_AES32ESI},   // R
+		{"bs 10011 rs2 rs1 000 rd 0110011 AES32ESMI", daTypeRn, emu_AES32ESMI}, // R
+	},
+}
+
+// ISArv32zknh SHA-512 Hash Functions
+var ISArv32zknh = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"0101110 rs2 rs1 000 rd 0110011 SHA512SIG0H", daTypeRa, emu_SHA512SIG0H}, // R
+		{"0101010 rs2 rs1 000 rd 0110011 SHA512SIG0L", daTypeRa, emu_SHA512SIG0L}, // R
+		{"0101111 rs2 rs1 000 rd 0110011 SHA512SIG1H", daTypeRa, emu_SHA512SIG1H}, // R
+		{"0101011 rs2 rs1 000 rd 0110011 SHA512SIG1L", daTypeRa, emu_SHA512SIG1L}, // R
+		{"0101000 rs2 rs1 000 rd 0110011 SHA512SUM0R", daTypeRa, emu_SHA512SUM0R}, // R
+		{"0101001 rs2 rs1 000 rd 0110011 SHA512SUM1R", daTypeRa, emu_SHA512SUM1R}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Bit Manipulation (RV64 only)
+
+// ISArv64zbb Basic Bit Manipulation
+var ISArv64zbb = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0000100 00000 rs1 100 rd 0111011 ZEXT.H", daTypeRm, emu_ZEXT_H}, // R
+		{"0110000 00000 rs1 001 rd 0011011 CLZW", daTypeRl, emu_CLZW},     // R
+		{"0110000 00001 rs1 001 rd 0011011 CTZW", daTypeRl, emu_CTZW},     // R
+		{"0110000 00010 rs1 001 rd 0011011 CPOPW", daTypeRl, emu_CPOPW},   // R
+		{"0110000 rs2 rs1 001 rd 0111011 ROLW", daTypeRa, emu_ROLW},       // R
+		{"0110000 rs2 rs1 101 rd 0111011 RORW", daTypeRa, emu_RORW},       // R
+		{"0110000 shamt5 rs1 101 rd 0011011 RORIW", daTypeId, emu_RORIW},  // I
+	},
+}
+
+// ISArv64zba Address Generation
+var ISArv64zba = ISAModule{
+	ext:  csr.IsaExtB,
+	ilen: 32,
+	defn: []insDefn{
+		{"0000100 rs2 rs1 000 rd 0111011 ADD.UW", daTypeRa, emu_ADD_UW},       // R
+		{"000010 shamt6 rs1 001 rd 0011011 SLLI.UW", daTypeId, emu_SLLI_UW},   // I
+		{"0010000 rs2 rs1 010 rd 0111011 SH1ADD.UW", daTypeRa, emu_SH1ADD_UW}, // R
+		{"0010000 rs2 rs1 100 rd 0111011 SH2ADD.UW", daTypeRa, emu_SH2ADD_UW}, // R
+		{"0010000 rs2 rs1 110 rd 0111011 SH3ADD.UW", daTypeRa, emu_SH3ADD_UW}, // R
+	},
+}
+
+//-----------------------------------------------------------------------------
+// Scalar Cryptography (RV64 only)
+
+// ISArv64zknd AES Decryption
+var ISArv64zknd = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"0011000 00000 rs1 001 rd 0010011 AES64IM", daTypeRl, emu_AES64IM},     // I
+		{"00110001 rnum rs1 001 rd 0010011 AES64KS1I", daTypeIl, emu_AES64KS1I}, // I
+		{"0111111 rs2 rs1 000 rd 0110011 AES64KS2", daTypeRa, emu_AES64KS2},     // R
+	},
+}
+
+// ISArv64zkne AES Encryption
+var ISArv64zkne = ISAModule{
+	ext:  csr.IsaExtK,
+	ilen: 32,
+	defn: []insDefn{
+		{"00110001 rnum rs1 001 rd 0010011 AES64KS1I", daTypeIl, emu_AES64KS1I}, // I
+		{"0111111 rs2 rs1 000 rd 0110011 AES64KS2", daTypeRa, emu_AES64KS2},     // R
+	},
+}
+
 //-----------------------------------------------------------------------------
 // pre-canned ISA module sets
 
@@ -414,6 +650,23 @@ var ISArv64gc = []ISAModule{
 	ISArv64c,
 }
 
+// ISArv32bkv = RV32 bit manipulation, scalar cryptography and vector extensions
+var ISArv32bkv = []ISAModule{
+	ISArv32zbbOnly, ISArv32zbb, ISArv32zba, ISArv32zbs, ISArv32zbc,
+	ISArv32zbkb, ISArv32zbkx,
+	ISArv32zksh, ISArv32zksed, ISArv32zknd, ISArv32zkne, ISArv32zknh,
+	ISArv32v,
+}
+
+// ISArv64bkv = RV64 bit manipulation, scalar cryptography and vector extensions
+var ISArv64bkv = []ISAModule{
+	ISArv32zbb, ISArv32zba, ISArv32zbs, ISArv32zbc,
+	ISArv32zbkb, ISArv32zbkx,
+	ISArv32zksh, ISArv32zksed,
+	ISArv32v,
+	ISArv64zbb, ISArv64zba, ISArv64zknd, ISArv64zkne,
+}
+
 //-----------------------------------------------------------------------------
 
 // insMeta is instruction meta-data determined at runtime
@@ -425,6 +678,15 @@ type insMeta struct {
 	dt        decodeType // decode type
 }
 
+// sameDecoder returns true if both instructions use the same disassembly and emulation functions.
+func (im *insMeta) sameDecoder(other *insMeta) bool {
+	if im.defn == nil || other.defn == nil {
+		return im.defn == other.defn
+	}
+	return reflect.ValueOf(im.defn.da).Pointer() == reflect.ValueOf(other.defn.da).Pointer() &&
+		reflect.ValueOf(im.defn.emu).Pointer() == reflect.ValueOf(other.defn.emu).Pointer()
+}
+
 // decodeConstant returns go code for decoding constants for this instruction.
 func (im *insMeta) decodeConstant() string {
 	s := []string{}
@@ -444,17 +706,20 @@ func (im *insMeta) decodeConstant() string {
 
 // ISA is an instruction set
 type ISA struct {
-	ext   uint       // ISA extension bits matching misa CSR
-	ins16 []*insMeta // the set of 16-bit instructions in the ISA
-	ins32 []*insMeta // the set of 32-bit instructions in the ISA
+	ext              uint       // ISA extension bits matching misa CSR
+	ins16            []*insMeta // the set of 16-bit instructions in the ISA
+	ins32            []*insMeta // the set of 32-bit instructions in the ISA
+	RecognizeHints   bool       // disassemble compressed HINT encodings as c.hint.*
+	RecognizePseudos bool       // disassemble pseudo-instructions in place of base instructions
 }
 
 // NewISA creates an empty instruction set.
 func NewISA(ext uint) *ISA {
 	return &ISA{
-		ext:   ext,
-		ins16: make([]*insMeta, 0),
-		ins32: make([]*insMeta, 0),
+		ext:              ext,
+		ins16:            make([]*insMeta, 0),
+		ins32:            make([]*insMeta, 0),
+		RecognizePseudos: true,
 	}
 }
 
@@ -477,6 +742,74 @@ func (isa *ISA) Add(module []ISAModule) error {
 	return nil
 }
 
+// Validate checks for instructions that can never be decoded because
+// an earlier instruction in the ISA matches all of their encodings.
+// A shadowing instruction with the same name is allowed if it uses the
+// same decoder.
+func (isa *ISA) Validate() error {
+	for _, set := range [][]*insMeta{isa.ins16, isa.ins32} {
+		for i, im := range set {
+			for _, prev := range set[:i] {
+				if im.mask&prev.mask != prev.mask || im.val&prev.mask != prev.val {
+					continue
+				}
+				if prev.name != im.name {
+					return fmt.Errorf("%s is shadowed by %s", im.name, prev.name)
+				}
+				if !prev.sameDecoder(im) {
+					return fmt.Errorf("%s is shadowed by %s with a different decoder", im.name, prev.name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergeIns appends the instructions in b that are not already in a.
+func mergeIns(a, b []*insMeta) ([]*insMeta, error) {
+	x := append([]*insMeta{}, a...)
+	for _, im := range b {
+		found := false
+		for _, prev := range a {
+			if prev.mask == im.mask && prev.val == im.val {
+				if prev.name != im.name {
+					return nil, fmt.Errorf("%s conflicts with %s", im.name, prev.name)
+				}
+				if !prev.sameDecoder(im) {
+					return nil, fmt.Errorf("%s conflicts with %s with a different decoder", im.name, prev.name)
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			x = append(x, im)
+		}
+	}
+	return x, nil
+}
+
+// Merge returns a new ISA containing the instructions of both ISAs.
+func (isa *ISA) Merge(other *ISA) (*ISA, error) {
+	x := NewISA(isa.ext | other.ext)
+	x.RecognizeHints = isa.RecognizeHints
+	x.RecognizePseudos = isa.RecognizePseudos
+	var err error
+	x.ins16, err = mergeIns(isa.ins16, other.ins16)
+	if err != nil {
+		return nil, err
+	}
+	x.ins32, err = mergeIns(isa.ins32, other.ins32)
+	if err != nil {
+		return nil, err
+	}
+	err = x.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
 // lookup returns the instruction meta information for an instruction.
 func (isa *ISA) lookup(ins uint) *insMeta {
 	if ins&3 == 3 {
@@ -0,0 +1,119 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V ISA Testing
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import (
+	"testing"
+
+	"github.com/deadsy/riscv/csr"
+)
+
+//-----------------------------------------------------------------------------
+
+func newTestISA(t *testing.T, module []ISAModule) *ISA {
+	isa := NewISA(0)
+	err := isa.Add(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return isa
+}
+
+func Test_Validate(t *testing.T) {
+	for _, module := range [][]ISAModule{
+		ISArv32gc, ISArv64gc,
+		append(append([]ISAModule{}, ISArv32gc...), ISArv32bkv...),
+		append(append([]ISAModule{}, ISArv64gc...), ISArv64bkv...),
+	} {
+		err := newTestISA(t, module).Validate()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	// zext.h is a special case of pack
+	for _, module := range [][]ISAModule{
+		{ISArv32i, ISArv32zbb, ISArv32zbbOnly, ISArv32zbkb},
+		{ISArv64i, ISArv32zbb, ISArv64zbb, ISArv32zbkb},
+	} {
+		err := newTestISA(t, module).Validate()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if newTestISA(t, []ISAModule{ISArv32i, ISArv32zbkb, ISArv32zbbOnly}).Validate() == nil {
+		t.Error("expected zext.h to be shadowed by pack")
+	}
+	// a shadowed instruction
+	isa := newTestISA(t, []ISAModule{ISArv32i})
+	isa.ins32 = append([]*insMeta{{name: "any", mask: 0x7f, val: 0x13}}, isa.ins32...)
+	if isa.Validate() == nil {
+		t.Error("expected a shadowed instruction error")
+	}
+	// a repeated instruction with the same decoder
+	isa = newTestISA(t, []ISAModule{ISArv32i, ISArv32i})
+	if err := isa.Validate(); err != nil {
+		t.Error(err)
+	}
+	// a repeated instruction with a different decoder
+	isa = newTestISA(t, []ISAModule{ISArv32i})
+	isa.ins32 = append([]*insMeta{withDecoder(isa.lookup(0x00a58593), daTypeRa)}, isa.ins32...)
+	if isa.Validate() == nil {
+		t.Error("expected a different decoder error")
+	}
+}
+
+// withDecoder returns a copy of the instruction with a different disassembly function.
+func withDecoder(im *insMeta, da daFunc) *insMeta {
+	x := *im
+	x.defn = &insDefn{im.defn.defn, da, im.defn.emu}
+	return &x
+}
+
+func Test_Merge(t *testing.T) {
+	// rv32i + rv32f
+	a := newTestISA(t, []ISAModule{ISArv32i, ISArv32m})
+	b := newTestISA(t, []ISAModule{ISArv32i, ISArv32f})
+	x, err := a.Merge(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.GetExtensions() != a.GetExtensions()|csr.IsaExtF {
+		t.Errorf("bad extensions %x", x.GetExtensions())
+	}
+	for _, v := range []daTest{
+		{0, 0x02b50533, "mul a0,a0,a1"},
+		{0, 0x00052007, "flw ft0,0(a0)"},
+		{0, 0x00a58593, "addi a1,a1,10"},
+	} {
+		if da := x.daInstruction(v.pc, v.ins, 32); da != v.da {
+			t.Errorf("ins %08x \"%s\" (expected) \"%s\" (actual)", v.ins, v.da, da)
+		}
+	}
+	if len(x.ins32) != len(a.ins32)+len(ISArv32f.defn) {
+		t.Errorf("duplicate instructions after merge")
+	}
+	// c.jal (rv32) and c.addiw (rv64) share an encoding
+	_, err = newTestISA(t, ISArv32gc).Merge(newTestISA(t, ISArv64gc))
+	if err == nil {
+		t.Error("expected a merge conflict")
+	}
+	// addi with a different decoder
+	b = newTestISA(t, []ISAModule{ISArv32i})
+	for i, im := range b.ins32 {
+		if im.name == "addi" {
+			b.ins32[i] = withDecoder(im, daTypeRa)
+		}
+	}
+	_, err = a.Merge(b)
+	if err == nil {
+		t.Error("expected a merge conflict")
+	}
+}
+
+//-----------------------------------------------------------------------------
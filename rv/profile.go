@@ -0,0 +1,94 @@
+//-----------------------------------------------------------------------------
+/*
+
+RISC-V Execution Profiling
+
+*/
+//-----------------------------------------------------------------------------
+
+package rv
+
+import (
+	"fmt"
+	"sort"
+
+	cli "github.com/deadsy/go-cli"
+)
+
+//-----------------------------------------------------------------------------
+
+// EnableProfiling starts counting instruction executions by PC.
+func (m *RV) EnableProfiling() {
+	if m.profile == nil {
+		m.profile = make(map[uint]uint64)
+	}
+}
+
+// DisableProfiling stops profiling and discards the counts.
+func (m *RV) DisableProfiling() {
+	m.profile = nil
+}
+
+// ProfileCounts returns the instruction execution counts by PC.
+func (m *RV) ProfileCounts() map[uint]uint64 {
+	return m.profile
+}
+
+//-----------------------------------------------------------------------------
+
+type profileEntry struct {
+	name  string // symbol name
+	count uint64 // instructions executed within the symbol
+}
+
+// sort profile entries by descending count
+type profileByCount []*profileEntry
+
+func (a profileByCount) Len() int      { return len(a) }
+func (a profileByCount) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a profileByCount) Less(i, j int) bool {
+	if a[i].count == a[j].count {
+		return a[i].name < a[j].name
+	}
+	return a[i].count > a[j].count
+}
+
+// ProfileReport returns a display string for the topN symbols by instruction count.
+func (m *RV) ProfileReport(topN int) string {
+	if len(m.profile) == 0 {
+		return "no profile data"
+	}
+	// group the counts by symbol
+	var total uint64
+	bySymbol := make(map[string]*profileEntry)
+	for pc, n := range m.profile {
+		total += n
+		name := m.Mem.AddrStr(pc)
+		if s := m.Mem.SymbolContaining(pc); s != nil {
+			name = s.Name
+		}
+		if e, ok := bySymbol[name]; ok {
+			e.count += n
+		} else {
+			bySymbol[name] = &profileEntry{name, n}
+		}
+	}
+	// sort by count
+	entries := []*profileEntry{}
+	for _, e := range bySymbol {
+		entries = append(entries, e)
+	}
+	sort.Sort(profileByCount(entries))
+	if topN > 0 && topN < len(entries) {
+		entries = entries[:topN]
+	}
+	// display string
+	s := make([][]string, len(entries))
+	for i, e := range entries {
+		pct := 100.0 * float64(e.count) / float64(total)
+		s[i] = []string{e.name, fmt.Sprintf("%d", e.count), fmt.Sprintf("%.2f%%", pct)}
+	}
+	return cli.TableString(s, []int{0, 0, 0}, 1)
+}
+
+//-----------------------------------------------------------------------------